@@ -1 +1 @@
-GOOS=linux GOARCH=arm64 go build -o mypibot-go-arm64 cmd/bot/main.go
\ No newline at end of file
+GOOS=linux GOARCH=arm64 go build -o mypibot-go-arm64 ./cmd/bot
\ No newline at end of file
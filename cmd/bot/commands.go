@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mypibot-go/internal/config"
+	"mypibot-go/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// checkConfig loads the configuration, reports what it found and verifies
+// the bot token with a getMe call.
+func checkConfig() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("configuration is invalid: %w", err)
+	}
+
+	fmt.Println("Configuration:")
+	fmt.Printf("  Allowed users:  %d\n", len(cfg.AllowedUsers))
+	fmt.Printf("  Database:       %s\n", cfg.DatabasePath)
+	fmt.Printf("  Backups:        %s\n", cfg.BackupDir)
+	fmt.Printf("  Storage mode:   %s\n", cfg.StorageMode)
+
+	if _, err := os.Stat(filepath.Dir(cfg.DatabasePath)); err != nil {
+		return fmt.Errorf("database directory is not accessible: %w", err)
+	}
+
+	api, err := tgbotapi.NewBotAPI(cfg.BotToken)
+	if err != nil {
+		return fmt.Errorf("bot token was rejected by the Telegram API: %w", err)
+	}
+	fmt.Printf("Token OK: authorized as @%s\n", api.Self.UserName)
+	return nil
+}
+
+// openDatabase opens the configured database for one-off CLI use. It never
+// recovers a damaged file, as the bot may have it open: moving it aside
+// would pull it out from under the running bot.
+func openDatabase() (*config.Config, *storage.Database, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Always open in default mode so CLI writes are not buffered
+	db, err := storage.NewDatabase(cfg.DatabasePath, storage.Options{
+		IntegrityCheck: cfg.IntegrityCheck,
+		NoRecover:      true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, db, nil
+}
+
+func reminders(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: reminders list|add|delete")
+	}
+
+	_, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "list":
+		all, err := db.ListAllReminders()
+		if err != nil {
+			return err
+		}
+		if len(all) == 0 {
+			fmt.Println("No reminders.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCHAT\tINTERVAL\tSTATUS\tNEXT TRIGGER\tMESSAGE")
+		for _, r := range all {
+			next := "-"
+			if r.NextTrigger.Valid {
+				next = r.NextTrigger.Time.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%d\t%dm\t%s\t%s\t%s\n",
+				r.ID, r.ChatID, r.Interval, r.Status, next, r.Message)
+		}
+		return w.Flush()
+
+	case "add":
+		if len(args) < 4 {
+			return fmt.Errorf("usage: reminders add <chat_id> <interval> <message>")
+		}
+		chatID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid chat ID: %v", err)
+		}
+		interval, err := strconv.Atoi(args[2])
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid interval %q: must be a positive number of minutes", args[2])
+		}
+
+		reminder, err := db.CreateReminder(chatID, interval, strings.Join(args[3:], " "))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reminder created. ID: %d\n", reminder.ID)
+		fmt.Println("A running bot schedules it on its next restart.")
+		return nil
+
+	case "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: reminders delete <id>")
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid reminder ID: %v", err)
+		}
+		if err := db.DeleteReminder(id); err != nil {
+			return err
+		}
+		fmt.Printf("Reminder %d deleted.\n", id)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown reminders command %q", args[0])
+	}
+}
+
+func database(args []string) error {
+	if len(args) == 0 || args[0] != "backup" {
+		return fmt.Errorf("usage: db backup [path]")
+	}
+
+	cfg, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	path := filepath.Join(cfg.BackupDir, storage.BackupFileName(cfg.DatabasePath, time.Now()))
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	if err := db.Backup(path); err != nil {
+		return err
+	}
+	fmt.Printf("Backup written to %s\n", path)
+	return nil
+}
+
+func send(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: send <chat_id> <text>")
+	}
+	chatID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid chat ID: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	api, err := tgbotapi.NewBotAPI(cfg.BotToken)
+	if err != nil {
+		return err
+	}
+
+	if _, err := api.Send(tgbotapi.NewMessage(chatID, strings.Join(args[1:], " "))); err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+	fmt.Println("Message sent.")
+	return nil
+}
@@ -1,24 +1,91 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"mypibot-go/internal/bot"
 	"mypibot-go/internal/config"
 )
 
+const usage = `Usage: mypibot-go [command] [arguments]
+
+Commands:
+  run                                        Start the bot (default)
+  check-config                               Validate .env and test the bot token
+  reminders list                             List all reminders in the database
+  reminders add <chat_id> <interval> <msg>   Create a reminder (interval in minutes);
+                                             a running bot schedules it on its next restart
+  reminders delete <id>                      Delete a reminder
+  db backup [path]                           Write a consistent copy of the database
+  send <chat_id> <text>                      Send a message as the bot
+  help                                       Show this message
+
+Commands other than run work directly on the database and the Telegram API,
+so they can be used over SSH while the bot is stopped. They never recover a
+damaged database; start the bot for that.
+`
+
 func main() {
+	args := os.Args[1:]
+	command := "run"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	var err error
+	switch command {
+	case "run":
+		err = runBot()
+	case "check-config":
+		err = checkConfig()
+	case "reminders":
+		err = reminders(args)
+	case "db":
+		err = database(args)
+	case "send":
+		err = send(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runBot() error {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// Initialize and start bot
 	b, err := bot.New(cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	// Stop cleanly on SIGINT/SIGTERM so buffered writes reach the disk
+	stopped := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+		log.Println("Shutting down...")
+		b.Stop()
+		close(stopped)
+	}()
+
 	// Start the bot
 	b.Start()
+	<-stopped
+	return nil
 }
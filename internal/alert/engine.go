@@ -0,0 +1,291 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricSource supplies the current value of every metric it knows about.
+type MetricSource interface {
+	Metrics() (map[string]float64, error)
+	// KnownMetric reports whether name is a metric rules may refer to.
+	KnownMetric(name string) bool
+}
+
+// EventSource reports discrete events, such as a power supply dropping
+// below spec, that are not threshold crossings. Events is polled on every
+// tick and each returned message is sent as is.
+type EventSource interface {
+	Events() ([]string, error)
+}
+
+// Notifier delivers an alert or recovery message.
+type Notifier func(text string)
+
+// Options configures an Engine. Hysteresis and Cooldown are the defaults
+// applied to rules created with NewRule.
+type Options struct {
+	Interval   time.Duration
+	Hysteresis float64
+	Cooldown   time.Duration
+}
+
+// Rule states reported in RuleStatus.State.
+const (
+	StateOK      = "ok"
+	StatePending = "pending"
+	StateFiring  = "firing"
+)
+
+// RuleStatus is a snapshot of a rule and where it is in its cycle.
+type RuleStatus struct {
+	Rule
+	State     string
+	LastValue float64
+	HasValue  bool
+}
+
+// ruleState tracks where a rule is in its ok → pending → firing cycle.
+type ruleState struct {
+	rule *Rule
+
+	pendingSince time.Time // when the threshold was first breached
+	firing       bool
+	notified     bool // whether the current firing was announced
+	lastAlert    time.Time
+	lastValue    float64
+	hasValue     bool
+}
+
+// Engine periodically evaluates rules against a MetricSource. Rules can be
+// added, muted and removed while it runs.
+type Engine struct {
+	mu      sync.Mutex
+	source  MetricSource
+	notify  Notifier
+	opts    Options
+	rules   []*ruleState
+	sources []EventSource
+}
+
+func NewEngine(source MetricSource, notify Notifier, opts Options, rules []*Rule) *Engine {
+	e := &Engine{
+		source: source,
+		notify: notify,
+		opts:   opts,
+	}
+	for _, rule := range rules {
+		e.rules = append(e.rules, &ruleState{rule: rule})
+	}
+	return e
+}
+
+// NewRule parses spec, applies the engine's default hysteresis and cooldown
+// and checks the rule refers to a known metric.
+func (e *Engine) NewRule(spec string) (*Rule, error) {
+	rule, err := ParseRule(spec)
+	if err != nil {
+		return nil, err
+	}
+	if !e.source.KnownMetric(rule.Metric) {
+		return nil, fmt.Errorf("unknown metric %q", rule.Metric)
+	}
+	rule.Hysteresis = e.opts.Hysteresis
+	rule.Cooldown = e.opts.Cooldown
+	return rule, nil
+}
+
+// AddRule starts evaluating rule on the next tick.
+func (e *Engine) AddRule(rule *Rule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = append(e.rules, &ruleState{rule: rule})
+}
+
+// AddSource registers an event source. Sources must be added before Run.
+func (e *Engine) AddSource(source EventSource) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sources = append(e.sources, source)
+}
+
+// RemoveRule stops evaluating the rule with the given ID.
+func (e *Engine) RemoveRule(id int64) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, state := range e.rules {
+		if state.rule.ID == id {
+			e.rules = append(e.rules[:i], e.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MuteRule suppresses notifications for a rule until the given time. A zero
+// time unmutes it.
+func (e *Engine) MuteRule(id int64, until time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, state := range e.rules {
+		if state.rule.ID == id {
+			state.rule.MutedUntil = until
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns a snapshot of every rule ordered by ID.
+func (e *Engine) Rules() []RuleStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]RuleStatus, 0, len(e.rules))
+	for _, state := range e.rules {
+		status := RuleStatus{
+			Rule:      *state.rule,
+			State:     StateOK,
+			LastValue: state.lastValue,
+			HasValue:  state.hasValue,
+		}
+		switch {
+		case state.firing:
+			status.State = StateFiring
+		case !state.pendingSince.IsZero():
+			status.State = StatePending
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+// Run evaluates the rules every interval until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			metrics, err := e.source.Metrics()
+			if err != nil {
+				log.Printf("Error collecting metrics for alerts: %v", err)
+			} else {
+				e.evaluate(metrics, time.Now())
+			}
+			e.pollSources()
+		}
+	}
+}
+
+func (e *Engine) evaluate(metrics map[string]float64, now time.Time) {
+	e.mu.Lock()
+	var messages []string
+	for _, state := range e.rules {
+		muted := now.Before(state.rule.MutedUntil)
+		if msg := state.step(metrics, now, muted); msg != "" {
+			messages = append(messages, msg)
+		}
+	}
+	e.mu.Unlock()
+
+	// Send outside the lock, delivery may be slow
+	for _, msg := range messages {
+		e.notify(msg)
+	}
+}
+
+func (e *Engine) pollSources() {
+	e.mu.Lock()
+	sources := e.sources
+	e.mu.Unlock()
+
+	for _, source := range sources {
+		events, err := source.Events()
+		if err != nil {
+			log.Printf("Error polling alert event source: %v", err)
+			continue
+		}
+		for _, event := range events {
+			e.notify(event)
+		}
+	}
+}
+
+// step advances the rule's state for one set of metrics and returns the
+// message to send, if any. A firing that starts while the rule is muted or
+// within its cooldown is tracked and announced on the first step after
+// both have passed. Only announced firings get a recovery message.
+func (s *ruleState) step(metrics map[string]float64, now time.Time, muted bool) string {
+	rule := s.rule
+
+	value, ok := metrics[rule.Metric]
+	s.lastValue, s.hasValue = value, ok
+	if !ok {
+		// No reading: do not start or extend a pending period
+		s.pendingSince = time.Time{}
+		return ""
+	}
+
+	if s.firing {
+		if rule.recovered(value) {
+			s.firing = false
+			s.pendingSince = time.Time{}
+			if !s.notified {
+				return ""
+			}
+			return fmt.Sprintf("✅ Recovered: %s is %s (rule #%d %s)", rule.Metric, formatValue(value), rule.ID, rule)
+		}
+		if s.notified || !s.canAlert(now, muted) {
+			return ""
+		}
+		s.notified = true
+		s.lastAlert = now
+		return fmt.Sprintf("🚨 Alert: %s is %s (rule #%d %s)", rule.Metric, formatValue(value), rule.ID, rule)
+	}
+
+	if !rule.breached(value) {
+		s.pendingSince = time.Time{}
+		return ""
+	}
+
+	if s.pendingSince.IsZero() {
+		s.pendingSince = now
+	}
+	if now.Sub(s.pendingSince) < rule.For {
+		return ""
+	}
+
+	s.firing = true
+	s.notified = s.canAlert(now, muted)
+	if !s.notified {
+		return ""
+	}
+	s.lastAlert = now
+
+	return fmt.Sprintf("🚨 Alert: %s is %s (rule #%d %s)", rule.Metric, formatValue(value), rule.ID, rule)
+}
+
+// canAlert reports whether an alert may be sent now: the rule is not muted
+// and its cooldown has passed.
+func (s *ruleState) canAlert(now time.Time, muted bool) bool {
+	return !muted && (s.lastAlert.IsZero() || now.Sub(s.lastAlert) >= s.rule.Cooldown)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
@@ -0,0 +1,246 @@
+package alert
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// missing stands for a tick without a reading of the rule's metric.
+var missing = math.NaN()
+
+// step is one evaluation at an offset from the start of a test.
+type step struct {
+	at    time.Duration
+	value float64
+	mute  time.Duration // if set, mute the rule until this offset first
+	want  string        // prefix of the expected message, "" for none
+	state string        // expected state afterwards, if set
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		hysteresis float64
+		cooldown   time.Duration
+		mutedFor   time.Duration // muted from the start for this long
+		steps      []step
+	}{
+		{
+			name: "fires after For",
+			spec: "cpu_percent>90:5m",
+			steps: []step{
+				{at: 0, value: 95, state: StatePending},
+				{at: 2 * time.Minute, value: 95},
+				{at: 5 * time.Minute, value: 96, want: "🚨 Alert: cpu_percent is 96.0", state: StateFiring},
+				{at: 6 * time.Minute, value: 97},
+				{at: 7 * time.Minute, value: 50, want: "✅ Recovered: cpu_percent is 50.0", state: StateOK},
+			},
+		},
+		{
+			name: "dip restarts the pending period",
+			spec: "cpu_percent>90:5m",
+			steps: []step{
+				{at: 0, value: 95},
+				{at: 3 * time.Minute, value: 80, state: StateOK},
+				{at: 6 * time.Minute, value: 95},
+				{at: 10 * time.Minute, value: 95, state: StatePending},
+				{at: 11 * time.Minute, value: 95, want: "🚨"},
+			},
+		},
+		{
+			name: "missing metric resets the pending period",
+			spec: "cpu_percent>90:5m",
+			steps: []step{
+				{at: 0, value: 95},
+				{at: 3 * time.Minute, value: missing, state: StateOK},
+				{at: 6 * time.Minute, value: 95},
+				{at: 10 * time.Minute, value: 95},
+				{at: 11 * time.Minute, value: 95, want: "🚨"},
+			},
+		},
+		{
+			name: "missing metric keeps a firing rule firing",
+			spec: "cpu_percent>90",
+			steps: []step{
+				{at: 0, value: 95, want: "🚨"},
+				{at: time.Minute, value: missing, state: StateFiring},
+				{at: 2 * time.Minute, value: 50, want: "✅"},
+			},
+		},
+		{
+			name:       "hysteresis",
+			spec:       "temperature>75",
+			hysteresis: 5,
+			steps: []step{
+				{at: 0, value: 76, want: "🚨"},
+				{at: time.Minute, value: 74, state: StateFiring},
+				{at: 2 * time.Minute, value: 70, state: StateFiring},
+				{at: 3 * time.Minute, value: 69.9, want: "✅"},
+			},
+		},
+		{
+			name:       "hysteresis below the threshold",
+			spec:       "sensor.garage.temperature<2",
+			hysteresis: 1,
+			steps: []step{
+				{at: 0, value: 1.5, want: "🚨"},
+				{at: time.Minute, value: 2.5},
+				{at: 2 * time.Minute, value: 3.5, want: "✅"},
+			},
+		},
+		{
+			name:     "cooldown suppresses a refiring that recovers",
+			spec:     "cpu_percent>90",
+			cooldown: time.Hour,
+			steps: []step{
+				{at: 0, value: 95, want: "🚨"},
+				{at: time.Minute, value: 50, want: "✅"},
+				{at: 2 * time.Minute, value: 95, state: StateFiring},
+				{at: 3 * time.Minute, value: 50, state: StateOK},
+			},
+		},
+		{
+			name:     "refiring that stays high is announced after the cooldown",
+			spec:     "cpu_percent>90",
+			cooldown: time.Hour,
+			steps: []step{
+				{at: 0, value: 95, want: "🚨"},
+				{at: time.Minute, value: 50, want: "✅"},
+				{at: 2 * time.Minute, value: 95},
+				{at: 59 * time.Minute, value: 95},
+				{at: time.Hour, value: 96, want: "🚨 Alert: cpu_percent is 96.0"},
+				{at: 61 * time.Minute, value: 96},
+				{at: 62 * time.Minute, value: 50, want: "✅"},
+			},
+		},
+		{
+			name:     "muted firing that recovers is never announced",
+			spec:     "disk_percent>90",
+			mutedFor: time.Hour,
+			steps: []step{
+				{at: 0, value: 95, state: StateFiring},
+				{at: 30 * time.Minute, value: 50, state: StateOK},
+				{at: 2 * time.Hour, value: 50},
+			},
+		},
+		{
+			name:     "muted firing is announced on unmute",
+			spec:     "disk_percent>90",
+			cooldown: time.Hour,
+			mutedFor: time.Hour,
+			steps: []step{
+				{at: 0, value: 95},
+				{at: 59 * time.Minute, value: 95},
+				// The muted firing did not start the cooldown
+				{at: time.Hour, value: 95, want: "🚨 Alert: disk_percent is 95.0"},
+				{at: 61 * time.Minute, value: 80, want: "✅"},
+			},
+		},
+		{
+			name: "announced firing recovers while muted",
+			spec: "disk_percent>90",
+			steps: []step{
+				{at: 0, value: 95, want: "🚨"},
+				{at: time.Minute, value: 95, mute: time.Hour},
+				{at: 2 * time.Minute, value: 80, want: "✅"},
+			},
+		},
+		{
+			name: "unmuting early",
+			spec: "disk_percent>90",
+			steps: []step{
+				{at: 0, value: 95, mute: time.Hour},
+				{at: time.Minute, value: 95},
+				{at: 2 * time.Minute, value: 95, mute: -1, want: "🚨"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+			rule, err := ParseRule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rule.ID = 1
+			rule.Hysteresis = tt.hysteresis
+			rule.Cooldown = tt.cooldown
+			if tt.mutedFor > 0 {
+				rule.MutedUntil = start.Add(tt.mutedFor)
+			}
+
+			var sent []string
+			e := NewEngine(nil, func(text string) { sent = append(sent, text) }, Options{}, []*Rule{rule})
+
+			for _, s := range tt.steps {
+				switch {
+				case s.mute < 0:
+					e.MuteRule(1, time.Time{})
+				case s.mute > 0:
+					e.MuteRule(1, start.Add(s.mute))
+				}
+
+				metrics := map[string]float64{"other": 1}
+				if !math.IsNaN(s.value) {
+					metrics[rule.Metric] = s.value
+				}
+				sent = nil
+				e.evaluate(metrics, start.Add(s.at))
+
+				switch {
+				case s.want == "" && len(sent) != 0:
+					t.Errorf("at %v: sent %q, want nothing", s.at, sent)
+				case s.want != "" && (len(sent) != 1 || !strings.HasPrefix(sent[0], s.want)):
+					t.Errorf("at %v: sent %q, want %q", s.at, sent, s.want)
+				}
+				if s.state != "" {
+					if got := e.Rules()[0].State; got != s.state {
+						t.Errorf("at %v: state %s, want %s", s.at, got, s.state)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	var sent []string
+	e := NewEngine(nil, func(text string) { sent = append(sent, text) }, Options{}, nil)
+
+	rules := []string{"cpu_percent>90", "temperature>75", "load<0.1"}
+	for i, spec := range rules {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rule.ID = int64(i + 1)
+		e.AddRule(rule)
+	}
+
+	now := time.Now()
+	e.evaluate(map[string]float64{"cpu_percent": 95, "temperature": 60, "load": 0.05}, now)
+	if len(sent) != 2 || !strings.Contains(sent[0], "rule #1") || !strings.Contains(sent[1], "rule #3") {
+		t.Errorf("sent %q, want alerts for rules 1 and 3", sent)
+	}
+
+	if !e.RemoveRule(1) || e.RemoveRule(1) {
+		t.Error("RemoveRule() did not remove rule 1 exactly once")
+	}
+	sent = nil
+	e.evaluate(map[string]float64{"cpu_percent": 10, "temperature": 60, "load": 0.05}, now.Add(time.Minute))
+	if len(sent) != 0 {
+		t.Errorf("sent %q after removing the rule", sent)
+	}
+
+	statuses := e.Rules()
+	if len(statuses) != 2 || statuses[0].ID != 2 || statuses[1].State != StateFiring || statuses[1].LastValue != 0.05 {
+		t.Errorf("Rules() = %+v", statuses)
+	}
+	if e.MuteRule(9, now) {
+		t.Error("MuteRule() of an unknown rule succeeded")
+	}
+}
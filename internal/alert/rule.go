@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule fires when Metric stays on the wrong side of Threshold for at least
+// For. It recovers once the value moves Hysteresis past the threshold in the
+// other direction, so a value hovering around the threshold does not flap.
+type Rule struct {
+	ID         int64
+	Metric     string
+	Op         string // ">" or "<"
+	Threshold  float64
+	For        time.Duration
+	Hysteresis float64
+	Cooldown   time.Duration // minimum time between two alerts for this rule
+	MutedUntil time.Time     // notifications are suppressed until then
+}
+
+// ParseRule parses a rule spec of the form "<metric><op><threshold>[:<for>]",
+// e.g. "cpu_percent>90:5m" or "temperature>75".
+func ParseRule(spec string) (*Rule, error) {
+	spec = strings.TrimSpace(spec)
+
+	condition, duration, _ := strings.Cut(spec, ":")
+	i := strings.IndexAny(condition, "<>")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid alert rule %q: expected <metric><op><threshold>[:<for>]", spec)
+	}
+
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(condition[i+1:]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert rule %q: bad threshold", spec)
+	}
+
+	rule := &Rule{
+		Metric:    strings.TrimSpace(condition[:i]),
+		Op:        condition[i : i+1],
+		Threshold: threshold,
+	}
+
+	if duration != "" {
+		rule.For, err = time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || rule.For < 0 {
+			return nil, fmt.Errorf("invalid alert rule %q: bad duration", spec)
+		}
+	}
+
+	return rule, nil
+}
+
+// breached reports whether value is on the alerting side of the threshold.
+func (r *Rule) breached(value float64) bool {
+	if r.Op == "<" {
+		return value < r.Threshold
+	}
+	return value > r.Threshold
+}
+
+// recovered reports whether value has moved far enough back to clear an
+// active alert.
+func (r *Rule) recovered(value float64) bool {
+	if r.Op == "<" {
+		return value > r.Threshold+r.Hysteresis
+	}
+	return value < r.Threshold-r.Hysteresis
+}
+
+// String formats the rule the way ParseRule accepts it.
+func (r *Rule) String() string {
+	s := fmt.Sprintf("%s%s%s", r.Metric, r.Op, strconv.FormatFloat(r.Threshold, 'f', -1, 64))
+	if r.For > 0 {
+		s += ":" + FormatDuration(r.For)
+	}
+	return s
+}
+
+// FormatDuration prints durations without trailing zero units ("5m", not
+// "5m0s").
+func FormatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
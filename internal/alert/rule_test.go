@@ -0,0 +1,56 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Rule
+		wantErr bool
+	}{
+		{spec: "cpu_percent>90:5m", want: Rule{Metric: "cpu_percent", Op: ">", Threshold: 90, For: 5 * time.Minute}},
+		{spec: "temperature>75", want: Rule{Metric: "temperature", Op: ">", Threshold: 75}},
+		{spec: " sensor.garage.temperature < 2.5 : 30m ", want: Rule{Metric: "sensor.garage.temperature", Op: "<", Threshold: 2.5, For: 30 * time.Minute}},
+		{spec: "mem_pressure>10:90s", want: Rule{Metric: "mem_pressure", Op: ">", Threshold: 10, For: 90 * time.Second}},
+		{spec: "disk_percent>-1:0s", want: Rule{Metric: "disk_percent", Op: ">", Threshold: -1}},
+		{spec: "cpu_percent", wantErr: true},
+		{spec: ">90", wantErr: true},
+		{spec: "cpu_percent>", wantErr: true},
+		{spec: "cpu_percent>high", wantErr: true},
+		{spec: "cpu_percent>90:soon", wantErr: true},
+		{spec: "cpu_percent>90:-5m", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRule(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRule(%q) = %+v, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tt.spec, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tt.spec, *got, tt.want)
+		}
+	}
+}
+
+func TestRuleString(t *testing.T) {
+	for _, spec := range []string{"cpu_percent>90:5m", "temperature>75", "load<0.5:1h", "mem_pressure>10:1m30s"} {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rule.String(); got != spec {
+			t.Errorf("ParseRule(%q).String() = %q", spec, got)
+		}
+	}
+}
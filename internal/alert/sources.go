@@ -0,0 +1,48 @@
+package alert
+
+import (
+	"errors"
+	"log"
+)
+
+// combinedSource merges the metrics of several sources.
+type combinedSource []MetricSource
+
+// CombineSources returns a MetricSource reporting the metrics of every
+// source, e.g. the local monitor and external sensors. A failing source
+// leaves its metrics out; Metrics only fails when every source does.
+func CombineSources(sources ...MetricSource) MetricSource {
+	return combinedSource(sources)
+}
+
+func (c combinedSource) Metrics() (map[string]float64, error) {
+	metrics := make(map[string]float64)
+	var errs []error
+	for _, source := range c {
+		m, err := source.Metrics()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for name, value := range m {
+			metrics[name] = value
+		}
+	}
+
+	if len(errs) == len(c) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		log.Printf("Error collecting metrics for alerts: %v", err)
+	}
+	return metrics, nil
+}
+
+func (c combinedSource) KnownMetric(name string) bool {
+	for _, source := range c {
+		if source.KnownMetric(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+// Package apt lists pending package updates and runs upgrades through
+// apt-get.
+package apt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"mypibot-go/internal/command"
+)
+
+// Package is one upgradable package.
+type Package struct {
+	Name       string
+	Suites     []string // e.g. ["stable-security"] or ["bookworm-updates"]
+	Version    string   // the candidate version
+	OldVersion string   // the installed version
+	Arch       string
+}
+
+// Security reports whether the new version comes from a security suite,
+// such as Debian's "bookworm-security" or Ubuntu's "jammy-security".
+func (p Package) Security() bool {
+	for _, suite := range p.Suites {
+		if strings.HasSuffix(suite, "-security") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseUpgradable parses the output of "apt list --upgradable", e.g.
+//
+//	openssl/stable-security 3.0.15-1~deb12u1 arm64 [upgradable from: 3.0.14-1~deb12u2]
+//
+// Other lines, such as "Listing..." and apt's CLI warning, are skipped.
+// Security updates come first, then packages by name.
+func ParseUpgradable(out string) []Package {
+	var packages []Package
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[3], "[") {
+			continue
+		}
+		name, suites, ok := strings.Cut(fields[0], "/")
+		if !ok || name == "" {
+			continue
+		}
+
+		p := Package{
+			Name:    name,
+			Suites:  strings.Split(suites, ","),
+			Version: fields[1],
+			Arch:    fields[2],
+		}
+		// The bracket text is translated, but the old version is always
+		// its last word
+		if last := strings.TrimRight(fields[len(fields)-1], "]"); last != "" && len(fields) > 4 {
+			p.OldVersion = last
+		}
+		packages = append(packages, p)
+	}
+
+	sort.SliceStable(packages, func(i, j int) bool {
+		if packages[i].Security() != packages[j].Security() {
+			return packages[i].Security()
+		}
+		return packages[i].Name < packages[j].Name
+	})
+	return packages
+}
+
+// ErrUpgradeRunning is returned when an upgrade is started while another
+// one is still running.
+var ErrUpgradeRunning = errors.New("an upgrade is already running")
+
+// Manager checks for and installs package updates. Refreshing the package
+// lists and upgrading run "sudo -n env DEBIAN_FRONTEND=noninteractive
+// apt-get", which needs a NOPASSWD rule.
+type Manager struct {
+	runner   command.Runner
+	streamer command.Streamer
+	root     string // where /run and /var/run are read from
+
+	mu        sync.Mutex
+	upgrading bool
+}
+
+func NewManager(runner command.Runner, streamer command.Streamer, root string) *Manager {
+	if root == "" {
+		root = "/"
+	}
+	return &Manager{runner: runner, streamer: streamer, root: root}
+}
+
+// Upgradable lists the upgradable packages from the current package lists.
+func (m *Manager) Upgradable(ctx context.Context) ([]Package, error) {
+	out, err := m.runner.Run(ctx, "apt", "list", "--upgradable")
+	if err != nil {
+		return nil, fmt.Errorf("error listing upgradable packages: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return ParseUpgradable(string(out)), nil
+}
+
+// aptGet returns the env arguments that run apt-get with args. sudo resets
+// the environment, so DEBIAN_FRONTEND is set through env; without it
+// debconf prompts would wait for a terminal that is not there.
+func aptGet(args ...string) []string {
+	return append([]string{"DEBIAN_FRONTEND=noninteractive", "apt-get"}, args...)
+}
+
+// Refresh downloads the current package lists.
+func (m *Manager) Refresh(ctx context.Context) error {
+	if _, err := command.Sudo(ctx, m.runner, "env", aptGet("update", "-q")...); err != nil {
+		return fmt.Errorf("error refreshing package lists (make sure NOPASSWD is configured in sudoers): %w", err)
+	}
+	return nil
+}
+
+// Upgrading reports whether an upgrade is running.
+func (m *Manager) Upgrading() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.upgrading
+}
+
+// Upgrade refreshes the package lists and installs every upgrade that
+// needs no package removals, passing apt-get's output to progress line by
+// line. Changed configuration files keep their local version.
+func (m *Manager) Upgrade(ctx context.Context, progress func(line string)) error {
+	m.mu.Lock()
+	if m.upgrading {
+		m.mu.Unlock()
+		return ErrUpgradeRunning
+	}
+	m.upgrading = true
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.upgrading = false
+		m.mu.Unlock()
+	}()
+
+	if err := command.SudoStream(ctx, m.streamer, progress, "env", aptGet("update", "-q")...); err != nil {
+		return fmt.Errorf("error refreshing package lists: %w", err)
+	}
+	err := command.SudoStream(ctx, m.streamer, progress, "env", aptGet("upgrade", "-y", "-q",
+		"-o", "Dpkg::Options::=--force-confdef",
+		"-o", "Dpkg::Options::=--force-confold")...)
+	if err != nil {
+		return fmt.Errorf("error upgrading packages: %w", err)
+	}
+	return nil
+}
+
+// RebootRequired reports whether installed updates need a reboot, and the
+// packages that asked for it when they are listed.
+func (m *Manager) RebootRequired() (bool, []string) {
+	// /var/run is a symlink to /run, which does not resolve under a host
+	// mount, so both are tried
+	for _, dir := range []string{"run", "var/run"} {
+		if _, err := os.Stat(filepath.Join(m.root, dir, "reboot-required")); err != nil {
+			continue
+		}
+
+		var pkgs []string
+		seen := make(map[string]bool)
+		data, _ := os.ReadFile(filepath.Join(m.root, dir, "reboot-required.pkgs"))
+		for _, pkg := range strings.Fields(string(data)) {
+			if !seen[pkg] {
+				seen[pkg] = true
+				pkgs = append(pkgs, pkg)
+			}
+		}
+		return true, pkgs
+	}
+	return false, nil
+}
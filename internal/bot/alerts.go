@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/alert"
+	"mypibot-go/internal/storage"
+)
+
+// addAlertRule handles /alert_add <metric><op><threshold>[:<for>].
+func (h *Handler) addAlertRule(args string) (string, error) {
+	spec := strings.Join(strings.Fields(args), "")
+	if spec == "" {
+		return "", fmt.Errorf("usage: /alert_add <metric><op><threshold>[:<for>], e.g. /alert_add cpu_percent>80:10m")
+	}
+
+	rule, err := h.alerts.NewRule(spec)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := h.db.CreateAlertRule(&storage.AlertRule{
+		Metric:     rule.Metric,
+		Op:         rule.Op,
+		Threshold:  rule.Threshold,
+		For:        rule.For,
+		Hysteresis: rule.Hysteresis,
+		Cooldown:   rule.Cooldown,
+	})
+	if err != nil {
+		return "", err
+	}
+	rule.ID = id
+	h.alerts.AddRule(rule)
+
+	return fmt.Sprintf("Alert rule created! ID: %d\n%s", id, rule), nil
+}
+
+// listAlertRules handles /alert_list.
+func (h *Handler) listAlertRules() string {
+	rules := h.alerts.Rules()
+	if len(rules) == 0 {
+		return "No alert rules. Add one with /alert_add."
+	}
+
+	now := time.Now()
+	var result strings.Builder
+	result.WriteString("Alert Rules:\n")
+	for _, r := range rules {
+		icon := "🟢"
+		switch r.State {
+		case alert.StatePending:
+			icon = "🟡"
+		case alert.StateFiring:
+			icon = "🔴"
+		}
+
+		result.WriteString(fmt.Sprintf("%s #%d %s", icon, r.ID, r.Rule.String()))
+		if r.HasValue {
+			result.WriteString(fmt.Sprintf(" (now %.1f)", r.LastValue))
+		}
+		if now.Before(r.MutedUntil) {
+			result.WriteString(fmt.Sprintf("\n   🔇 muted for %s", alert.FormatDuration(r.MutedUntil.Sub(now).Round(time.Minute))))
+		}
+		result.WriteString("\n")
+	}
+	result.WriteString(fmt.Sprintf("\nKnown metrics: %s", strings.Join(h.metricNames(), ", ")))
+
+	return result.String()
+}
+
+// muteAlertRule handles /alert_mute <id> <duration>. A duration of "off" or
+// "0" unmutes the rule.
+func (h *Handler) muteAlertRule(args string) (string, error) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return "", fmt.Errorf("usage: /alert_mute <id> <duration>, e.g. /alert_mute 3 2h")
+	}
+
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid rule ID: %v", err)
+	}
+
+	var until time.Time
+	if fields[1] != "off" && fields[1] != "0" {
+		duration, err := time.ParseDuration(fields[1])
+		if err != nil || duration <= 0 {
+			return "", fmt.Errorf("invalid duration %q, use e.g. 30m or 2h", fields[1])
+		}
+		until = time.Now().Add(duration)
+	}
+
+	if err := h.db.MuteAlertRule(id, until); err != nil {
+		return "", err
+	}
+	h.alerts.MuteRule(id, until)
+
+	if until.IsZero() {
+		return fmt.Sprintf("🔔 Alert rule #%d unmuted", id), nil
+	}
+	return fmt.Sprintf("🔇 Alert rule #%d muted until %s", id, until.Format("Jan 2 15:04")), nil
+}
+
+// deleteAlertRule handles /alert_delete <id>.
+func (h *Handler) deleteAlertRule(args string) (string, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("usage: /alert_delete <id>")
+	}
+
+	if err := h.db.DeleteAlertRule(id); err != nil {
+		return "", err
+	}
+	h.alerts.RemoveRule(id)
+
+	return fmt.Sprintf("🗑️ Alert rule #%d deleted", id), nil
+}
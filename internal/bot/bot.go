@@ -1,10 +1,25 @@
 package bot
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
+	"mypibot-go/internal/alert"
+	"mypibot-go/internal/apt"
+	"mypibot-go/internal/command"
 	"mypibot-go/internal/config"
+	"mypibot-go/internal/docker"
+	"mypibot-go/internal/gpio"
+	"mypibot-go/internal/logs"
+	"mypibot-go/internal/monitor"
+	"mypibot-go/internal/probe"
+	"mypibot-go/internal/sensor"
 	"mypibot-go/internal/storage"
+	"mypibot-go/internal/systemd"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -12,8 +27,31 @@ import (
 type Bot struct {
 	api          *tgbotapi.BotAPI
 	allowedUsers map[int64]bool
+	adminUsers   []int64
 	handler      *Handler
 	db           *storage.Database
+	retention    []storage.RetentionPolicy
+	dbPath       string
+	backupDir    string
+	backupEvery  time.Duration // 0 when periodic backups are off
+	backupKeep   int
+	alertChats   []int64
+	monitor      *monitor.Monitor
+	alerts       *alert.Engine
+	probes       *probe.Runner
+	addresses    *monitor.AddressWatcher
+	watchdog     *monitor.Watchdog
+	gpio         *gpio.Controller
+	sensors      *sensor.Registry
+	sensorServer *sensor.Server // nil when SENSOR_LISTEN is not set
+	updates      *apt.Manager
+	updateDigest *config.Weekly // nil when the digest is off
+
+	// ctx is cancelled by Stop to end background jobs, and jobs tracks
+	// them so the database outlives them
+	ctx    context.Context
+	cancel context.CancelFunc
+	jobs   sync.WaitGroup
 }
 
 func New(cfg *config.Config) (*Bot, error) {
@@ -28,21 +66,128 @@ func New(cfg *config.Config) (*Bot, error) {
 		allowedUsers[id] = true
 	}
 
+	var retention []storage.RetentionPolicy
+	for _, r := range cfg.Retention {
+		retention = append(retention, storage.RetentionPolicy{
+			Table:   r.Table,
+			MaxAge:  r.MaxAge,
+			MaxRows: r.MaxRows,
+		})
+	}
+	if err := storage.ValidateRetention(retention); err != nil {
+		return nil, err
+	}
+
 	// Initialize database
-	db, err := storage.NewDatabase(cfg.DatabasePath)
+	db, err := storage.NewDatabase(cfg.DatabasePath, storage.Options{
+		Mode:           cfg.StorageMode,
+		FlushInterval:  cfg.FlushInterval,
+		IntegrityCheck: cfg.IntegrityCheck,
+		BackupDir:      cfg.BackupDir,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	// Create bot instance
+	ctx, cancel := context.WithCancel(context.Background())
 	bot := &Bot{
 		api:          api,
 		allowedUsers: allowedUsers,
+		adminUsers:   cfg.AdminUsers,
 		db:           db,
+		retention:    retention,
+		dbPath:       cfg.DatabasePath,
+		backupDir:    cfg.BackupDir,
+		backupEvery:  cfg.BackupInterval,
+		backupKeep:   cfg.BackupKeep,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+
+	mon := monitor.New(cfg.HostRoot)
+	bot.monitor = mon
+	bot.addresses = monitor.NewAddressWatcher(cfg.HostRoot)
+
+	bot.sensors, err = loadSensors(db, cfg.SensorStaleAfter)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if cfg.SensorListen != "" {
+		bot.sensorServer = sensor.NewServer(cfg.SensorListen, cfg.SensorToken, bot.sensors)
+	}
+	metrics := alert.CombineSources(mon, bot.sensors)
+
+	rules, err := loadAlertRules(cfg, db, metrics)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	bot.alertChats = cfg.AlertChats
+	bot.alerts = alert.NewEngine(metrics, bot.sendAlert, alert.Options{
+		Interval:   cfg.AlertInterval,
+		Hysteresis: cfg.AlertHysteresis,
+		Cooldown:   cfg.AlertCooldown,
+	}, rules)
+	bot.alerts.AddSource(monitor.NewPowerWatcher(mon, cfg.AlertCooldown))
+	bot.alerts.AddSource(bot.sensors)
+
+	probes, err := loadProbes(cfg.Probes, cfg.ProbeTimeout)
+	if err != nil {
+		db.Close()
+		return nil, err
 	}
+	bot.probes = probe.NewRunner(probes, db)
+	bot.alerts.AddSource(bot.probes)
+
+	watches, err := loadWatches(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	bot.watchdog = monitor.NewWatchdog(cfg.HostRoot, cfg.WatchRestart, cfg.AlertCooldown, watches)
+	bot.alerts.AddSource(bot.watchdog)
+
+	logRules, err := loadLogRules(cfg.LogAlerts)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if len(logRules) > 0 {
+		bot.alerts.AddSource(logs.NewFollower(command.Exec{}, cfg.HostRoot, logRules, cfg.LogAlertInterval))
+	}
+
+	dockerClient := docker.NewClient(cfg.DockerSocket)
+	bot.alerts.AddSource(docker.NewWatcher(dockerClient))
+
+	gpioLines, err := loadGPIOLines(cfg.GPIOLines)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	openChip := gpio.OpenChip
+	if cfg.GPIOBackend == "sim" {
+		openChip = gpio.SimOpener(64)
+	}
+	bot.gpio = gpio.NewController(gpioLines, openChip)
+
+	bot.updates = apt.NewManager(command.Exec{}, command.Exec{}, cfg.HostRoot)
+	bot.updateDigest = cfg.UpdateDigest
 
 	// Create handler with database
-	bot.handler = NewHandler(db, api)
+	bot.handler = NewHandler(db, api, Services{
+		Monitor:  mon,
+		Alerts:   bot.alerts,
+		Probes:   bot.probes,
+		Watchdog: bot.watchdog,
+		Systemd:  systemd.NewManager(command.Exec{}, cfg.ServiceUnits),
+		Logs:     logs.NewReader(command.Exec{}, cfg.HostRoot, cfg.LogSources),
+		Docker:   dockerClient,
+		GPIO:     bot.gpio,
+		Sensors:  bot.sensors,
+		Updates:  bot.updates,
+	}, cfg.AdminUsers)
 
 	// Recover active reminders
 	if err := bot.recoverReminders(); err != nil {
@@ -73,12 +218,27 @@ func (b *Bot) recoverReminders() error {
 func (b *Bot) Start() {
 	log.Printf("Authorized on account %s", b.api.Self.UserName)
 
+	if report := b.db.Recovery(); report != nil {
+		b.notifyAdmins(report.String())
+	}
+
+	b.startJobs()
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	updates := b.api.GetUpdatesChan(u)
 
 	for update := range updates {
+		if update.CallbackQuery != nil {
+			if !b.allowedUsers[update.CallbackQuery.From.ID] {
+				b.api.Request(tgbotapi.NewCallback(update.CallbackQuery.ID, "❌ You are not authorized to use this bot."))
+				continue
+			}
+			b.handler.HandleCallback(b.api, update.CallbackQuery)
+			continue
+		}
+
 		if update.Message == nil {
 			continue
 		}
@@ -93,9 +253,118 @@ func (b *Bot) Start() {
 	}
 }
 
-// Add cleanup method
+// loadAlertRules imports the configured alert rules on first start and
+// returns the rules stored in the database. Rules for metrics the source
+// does not know are skipped.
+func loadAlertRules(cfg *config.Config, db *storage.Database, source alert.MetricSource) ([]*alert.Rule, error) {
+	var defaults []*storage.AlertRule
+	var configured []*alert.Rule
+	for _, spec := range cfg.AlertRules {
+		rule, err := alert.ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		if !source.KnownMetric(rule.Metric) {
+			return nil, fmt.Errorf("invalid alert rule %q: unknown metric %q", spec, rule.Metric)
+		}
+		configured = append(configured, rule)
+		defaults = append(defaults, &storage.AlertRule{
+			Metric:     rule.Metric,
+			Op:         rule.Op,
+			Threshold:  rule.Threshold,
+			For:        rule.For,
+			Hysteresis: cfg.AlertHysteresis,
+			Cooldown:   cfg.AlertCooldown,
+		})
+	}
+	seeded, err := db.SeedAlertRules(defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := db.ListAlertRules()
+	if err != nil {
+		return nil, err
+	}
+
+	// ALERT_RULES is only imported on first start; say so when it has since
+	// been edited, as the edit would otherwise go unnoticed
+	if !seeded {
+		have := make(map[string]bool)
+		for _, r := range stored {
+			have[(&alert.Rule{Metric: r.Metric, Op: r.Op, Threshold: r.Threshold, For: r.For}).String()] = true
+		}
+		for _, rule := range configured {
+			if !have[rule.String()] {
+				log.Printf("ALERT_RULES entry %s is not among the stored rules and is ignored: ALERT_RULES is only imported on first start, use /alert_add", rule)
+			}
+		}
+	}
+
+	var rules []*alert.Rule
+	for _, r := range stored {
+		if !source.KnownMetric(r.Metric) {
+			log.Printf("Skipping alert rule %d: unknown metric %q", r.ID, r.Metric)
+			continue
+		}
+		rule := &alert.Rule{
+			ID:         r.ID,
+			Metric:     r.Metric,
+			Op:         r.Op,
+			Threshold:  r.Threshold,
+			For:        r.For,
+			Hysteresis: r.Hysteresis,
+			Cooldown:   r.Cooldown,
+		}
+		if r.MutedUntil.Valid {
+			rule.MutedUntil = r.MutedUntil.Time
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// notifyAdmins sends text to every admin's private chat.
+func (b *Bot) notifyAdmins(text string) {
+	b.notify(b.adminUsers, text)
+}
+
+// sendAlert delivers an alert engine message to the alert chats.
+func (b *Bot) sendAlert(text string) {
+	b.notify(b.alertChats, text)
+}
+
+// notify sends text to each chat, returning the errors of the sends that
+// failed.
+func (b *Bot) notify(chatIDs []int64, text string) error {
+	var errs []error
+	for _, id := range chatIDs {
+		if _, err := b.api.Send(tgbotapi.NewMessage(id, text)); err != nil {
+			log.Printf("Error notifying chat %d: %v", id, err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stop ends the update loop and closes the database, flushing any
+// buffered writes. Background jobs and commands still running, such as an
+// upgrade, are waited for first so none of them writes to a closed
+// database.
 func (b *Bot) Stop() {
+	b.cancel()
+	b.api.StopReceivingUpdates()
+	b.jobs.Wait()
+	if b.updates.Upgrading() {
+		log.Printf("Waiting for the running upgrade to finish...")
+	}
+	b.handler.Wait()
+	if err := b.gpio.Close(); err != nil {
+		log.Printf("Error releasing GPIO lines: %v", err)
+	}
 	if b.db != nil {
-		b.db.Close()
+		if err := b.db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
 	}
 }
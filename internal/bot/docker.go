@@ -0,0 +1,170 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"mypibot-go/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const dockerUsage = `Usage:
+/docker ps - list containers
+/docker stats <name> - CPU, memory, network and block I/O
+/docker logs <name> [n] - last n log lines
+/docker restart <name> - restart a container (admin only)`
+
+// handleDocker handles /docker ps|stats|logs|restart.
+func (h *Handler) handleDocker(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		args = []string{"ps"}
+	}
+
+	ctx := context.Background()
+	var text string
+	var err error
+	switch {
+	case args[0] == "ps" && len(args) == 1:
+		text, err = h.dockerPS(ctx)
+
+	case args[0] == "stats" && len(args) == 2:
+		text, err = h.dockerStats(ctx, args[1])
+
+	case args[0] == "logs" && (len(args) == 2 || len(args) == 3):
+		n := defaultLogLines
+		if len(args) == 3 {
+			n, err = strconv.Atoi(args[2])
+			if err != nil || n < 1 || n > maxLogLines {
+				return fmt.Errorf("number of lines must be between 1 and %d", maxLogLines)
+			}
+		}
+		return h.dockerLogs(ctx, bot, message.Chat.ID, args[1], n)
+
+	case args[0] == "restart" && len(args) == 2:
+		if !h.isAdmin(message.From.ID) {
+			return errAdminOnly
+		}
+		text, err = h.dockerRestart(ctx, message.From.ID, args[1])
+
+	default:
+		return errors.New(dockerUsage)
+	}
+
+	if err != nil {
+		return err
+	}
+	return h.reply(bot, message.Chat.ID, text)
+}
+
+func (h *Handler) dockerPS(ctx context.Context) (string, error) {
+	containers, err := h.docker.Containers(ctx, true)
+	if err != nil {
+		return "", err
+	}
+	if len(containers) == 0 {
+		return "No containers.", nil
+	}
+
+	var result strings.Builder
+	result.WriteString("Containers:\n")
+	for _, c := range containers {
+		icon := "⚪"
+		switch {
+		case c.Health() == "unhealthy" || c.State == "dead" || c.State == "restarting":
+			icon = "🔴"
+		case c.State == "running":
+			icon = "🟢"
+		}
+		result.WriteString(fmt.Sprintf("\n%s %s (%s)\n  %s\n", icon, c.Name(), c.Image, c.Status))
+	}
+	return result.String(), nil
+}
+
+func (h *Handler) dockerStats(ctx context.Context, name string) (string, error) {
+	c, err := h.docker.Find(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if c.State != "running" {
+		return fmt.Sprintf("%s is not running: %s", c.Name(), c.Status), nil
+	}
+
+	stats, err := h.docker.Stats(ctx, c.ID)
+	if err != nil {
+		return "", err
+	}
+
+	text := fmt.Sprintf("🐳 %s (%s)\n%s\n\nCPU: %.1f%%\nMemory: %s",
+		c.Name(), c.Image, c.Status, stats.CPUPercent, formatSize(stats.MemoryUsage))
+	if stats.MemoryLimit > 0 {
+		text += fmt.Sprintf(" / %s (%.1f%%)", formatSize(stats.MemoryLimit),
+			float64(stats.MemoryUsage)/float64(stats.MemoryLimit)*100)
+	}
+	text += fmt.Sprintf("\nNetwork: ↓ %s ↑ %s\nBlock I/O: read %s, write %s\nPIDs: %d",
+		formatSize(stats.NetRx), formatSize(stats.NetTx),
+		formatSize(stats.BlockRead), formatSize(stats.BlockWrite), stats.PIDs)
+	return text, nil
+}
+
+func (h *Handler) dockerLogs(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, name string, n int) error {
+	c, err := h.docker.Find(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	text, err := h.docker.Logs(ctx, c.ID, n)
+	if err != nil {
+		return err
+	}
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return h.reply(bot, chatID, "No log lines.")
+	}
+	return h.replyOrDocument(bot, chatID, text, c.Name()+".log", fmt.Sprintf("Last %d lines of %s", n, c.Name()))
+}
+
+func (h *Handler) dockerRestart(ctx context.Context, userID int64, name string) (string, error) {
+	c, err := h.docker.Find(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	err = h.docker.Restart(ctx, c.ID)
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	if auditErr := h.db.AddAuditEntry(&storage.AuditEntry{
+		UserID: userID,
+		Action: "docker restart",
+		Target: c.Name(),
+		Result: result,
+	}); auditErr != nil {
+		log.Printf("Error recording audit entry: %v", auditErr)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("✅ Restarted %s.", c.Name()), nil
+}
+
+// formatSize renders a byte count with a binary unit, e.g. "1.5 MB".
+func formatSize(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"mypibot-go/internal/gpio"
+	"mypibot-go/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const gpioUsage = `Usage:
+/gpio - list configured lines
+/gpio get <line>
+/gpio set <line> <0|1> (admin only)
+/gpio watch <line> [off]`
+
+// loadGPIOLines parses the configured GPIO line specs.
+func loadGPIOLines(specs []string) ([]*gpio.Line, error) {
+	var lines []*gpio.Line
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		line, err := gpio.ParseLine(spec)
+		if err != nil {
+			return nil, err
+		}
+		if seen[line.Name] {
+			return nil, fmt.Errorf("invalid GPIO line %q: duplicate name %q", spec, line.Name)
+		}
+		seen[line.Name] = true
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// handleGPIO handles /gpio [get|set|watch <line> ...].
+func (h *Handler) handleGPIO(bot *tgbotapi.BotAPI, message *tgbotapi.Message) (string, error) {
+	if len(h.gpio.Lines()) == 0 {
+		return "No GPIO lines are configured. Set GPIO_LINES in .env.", nil
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		return h.listGPIOLines(), nil
+	}
+
+	switch {
+	case args[0] == "get" && len(args) == 2:
+		value, err := h.gpio.Get(args[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s: %d", args[1], value), nil
+
+	case args[0] == "set" && len(args) == 3:
+		if !h.isAdmin(message.From.ID) {
+			return "", errAdminOnly
+		}
+		if args[2] != "0" && args[2] != "1" {
+			return "", fmt.Errorf("value must be 0 or 1")
+		}
+		value := int(args[2][0] - '0')
+
+		err := h.gpio.Set(args[1], value)
+		result := "ok"
+		if err != nil {
+			result = err.Error()
+		}
+		if auditErr := h.db.AddAuditEntry(&storage.AuditEntry{
+			UserID: message.From.ID,
+			Action: "gpio set " + args[2],
+			Target: args[1],
+			Result: result,
+		}); auditErr != nil {
+			log.Printf("Error recording audit entry: %v", auditErr)
+		}
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("✅ %s set to %d.", args[1], value), nil
+
+	case args[0] == "watch" && len(args) == 2:
+		chatID := message.Chat.ID
+		err := h.gpio.Watch(args[1], func(event gpio.Event, err error) {
+			text := fmt.Sprintf("🔔 %s changed to %d at %s", event.Line, event.Value, event.At.Format("15:04:05"))
+			if err != nil {
+				text = fmt.Sprintf("⚠️ Stopped watching %s: %v", event.Line, err)
+			}
+			if _, sendErr := bot.Send(tgbotapi.NewMessage(chatID, text)); sendErr != nil {
+				log.Printf("Error sending GPIO event: %v", sendErr)
+			}
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("👀 Watching %s. Edges will be reported here until /gpio watch %s off.", args[1], args[1]), nil
+
+	case args[0] == "watch" && len(args) == 3 && args[2] == "off":
+		if err := h.gpio.Unwatch(args[1]); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Stopped watching %s.", args[1]), nil
+	}
+
+	return "", errors.New(gpioUsage)
+}
+
+// listGPIOLines shows every configured line with its current value.
+func (h *Handler) listGPIOLines() string {
+	var result strings.Builder
+	result.WriteString("GPIO lines:\n")
+	for _, line := range h.gpio.Lines() {
+		direction := "in"
+		if line.Output {
+			direction = "out"
+		}
+		result.WriteString(fmt.Sprintf("\n%s (%s line %d, %s", line.Name, line.Chip, line.Offset, direction))
+		if line.ActiveLow {
+			result.WriteString(", active-low")
+		}
+		if h.gpio.Watching(line.Name) {
+			result.WriteString(", watched")
+		}
+		result.WriteString("): ")
+
+		value, err := h.gpio.Get(line.Name)
+		if err != nil {
+			result.WriteString(fmt.Sprintf("⚠️ %v", err))
+		} else {
+			result.WriteString(fmt.Sprint(value))
+		}
+	}
+	return result.String()
+}
@@ -2,11 +2,21 @@ package bot
 
 import (
 	"fmt"
+	"mypibot-go/internal/alert"
+	"mypibot-go/internal/apt"
+	"mypibot-go/internal/docker"
+	"mypibot-go/internal/gpio"
+	"mypibot-go/internal/logs"
 	"mypibot-go/internal/monitor"
+	"mypibot-go/internal/probe"
+	"mypibot-go/internal/sensor"
 	"mypibot-go/internal/reminder"
 	"mypibot-go/internal/storage"
+	"mypibot-go/internal/systemd"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -14,15 +24,86 @@ import (
 type Handler struct {
 	monitor  *monitor.Monitor
 	reminder *reminder.Manager
+	alerts   *alert.Engine
+	probes   *probe.Runner
+	watchdog *monitor.Watchdog
+	services *systemd.Manager
+	logs     *logs.Reader
+	docker   *docker.Client
+	gpio     *gpio.Controller
+	sensors  *sensor.Registry
+	updates  *apt.Manager
+	db       *storage.Database
+	admins   map[int64]bool
+
+	// tasks tracks commands still running after their reply, such as an
+	// upgrade, so Wait can let them finish
+	tasks sync.WaitGroup
+}
+
+// Services are the background subsystems commands report on and control.
+type Services struct {
+	Monitor  *monitor.Monitor
+	Alerts   *alert.Engine
+	Probes   *probe.Runner
+	Watchdog *monitor.Watchdog
+	Systemd  *systemd.Manager
+	Logs     *logs.Reader
+	Docker   *docker.Client
+	GPIO     *gpio.Controller
+	Sensors  *sensor.Registry
+	Updates  *apt.Manager
 }
 
-func NewHandler(db *storage.Database, bot *tgbotapi.BotAPI) *Handler {
+var errAdminOnly = fmt.Errorf("this command is for admins only")
+
+func NewHandler(db *storage.Database, bot *tgbotapi.BotAPI, services Services, adminUsers []int64) *Handler {
+	admins := make(map[int64]bool)
+	for _, id := range adminUsers {
+		admins[id] = true
+	}
+
 	return &Handler{
-		monitor:  monitor.New(),
+		monitor:  services.Monitor,
 		reminder: reminder.NewManager(db, bot),
+		alerts:   services.Alerts,
+		probes:   services.Probes,
+		watchdog: services.Watchdog,
+		services: services.Systemd,
+		logs:     services.Logs,
+		docker:   services.Docker,
+		gpio:     services.GPIO,
+		sensors:  services.Sensors,
+		updates:  services.Updates,
+		db:       db,
+		admins:   admins,
 	}
 }
 
+// background runs a command's remaining work off the update loop.
+func (h *Handler) background(task func()) {
+	h.tasks.Add(1)
+	go func() {
+		defer h.tasks.Done()
+		task()
+	}()
+}
+
+// Wait waits for commands still running in the background.
+func (h *Handler) Wait() {
+	h.tasks.Wait()
+}
+
+func (h *Handler) isAdmin(userID int64) bool {
+	return h.admins[userID]
+}
+
+// metricNames lists the metrics alert rules may use.
+func (h *Handler) metricNames() []string {
+	names := append([]string{}, monitor.MetricNames...)
+	return append(names, sensor.MetricPrefix+"<sensor>.<metric>")
+}
+
 func (h *Handler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	var text string
 	var err error
@@ -33,19 +114,44 @@ func (h *Handler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 
 <b>📊 System Monitoring</b>
 • /help - Show this help message
-• /status - Show CPU and RAM usage
-• /temp - Show CPU temperature
+• /status - Show CPU, load and RAM usage
+• /temp - Show temperatures and trend
+• /mem - Show memory, swap, zram and memory pressure
 • /uptime - Show system uptime
-• /top - Show top 5 processes
-• /disk - Show disk usage
+• /power - Show under-voltage and throttling state
+• /hw - Show board model, revision, SoC, memory, OS, boot device and USB devices
+• /top [cpu|mem|io] [n] [filter] [group] - Show top processes (takes about a second), e.g. /top mem 10 python
+• /disk - Show disk space, inodes, I/O, wear and fill projection
 • /network_details - Show network details
+• /sensors - Show the latest readings pushed by external sensors
+• /probes - Show reachability probes with latency and uptime
+• /service - Show allowed systemd units
+• /service status &lt;unit&gt; - Show a unit's state, memory and restarts
+• /service start|stop|restart &lt;unit&gt; - Control a unit (admin only)
+• /logs &lt;unit|file|kernel&gt; [n] [grep] - Show the last n log lines, e.g. /logs nginx 50 error
+• /gpio - Show configured GPIO lines and their values
+• /gpio get &lt;line&gt; - Read a GPIO line
+• /gpio set &lt;line&gt; &lt;0|1&gt; - Drive an output line (admin only)
+• /gpio watch &lt;line&gt; [off] - Report edges on an input line in this chat
+• /docker ps - List containers
+• /docker stats &lt;name&gt; - Show a container's CPU, memory and I/O
+• /docker logs &lt;name&gt; [n] - Show a container's last n log lines
+• /docker restart &lt;name&gt; - Restart a container (admin only)
+• /watch - Show watched processes
+• /watch add &lt;name&gt; &lt;name|cmdline|pidfile&gt; &lt;pattern&gt; - Watch a process (admin only)
+• /watch delete &lt;id&gt; - Stop watching a process (admin only)
+• /kill &lt;pid&gt; [signal] - Signal a process after confirmation, default TERM (admin only)
+• /renice &lt;pid&gt; &lt;n&gt; - Change a process's nice value after confirmation (admin only)
+• /updates [refresh] - List upgradable packages, security updates first
+• /upgrade - Install package upgrades after confirmation (admin only)
 • /reboot - Reboot the system (admin only)
+• /storage - Show table sizes and database size (admin only)
 
 
 <b>⏰ Reminder Commands</b>
 
 <b>Create New Reminder:</b>
-/reminder_create  &lt;interval&gt; &lt;message&gt;
+/reminder_create  [interval] &lt;message&gt;
 
 <b>Examples:</b>
 • /reminder_create water 120 "Drink water! 💧"
@@ -61,8 +167,19 @@ func (h *Handler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 • /reminder_eye_drop - Start eye drops (2h)
 • /reminder_water - Start water (2h)
 
+<b>🚨 Alerts</b>
+• /alert_list - Show alert rules and their state
+• /alert_add &lt;metric&gt;&lt;op&gt;&lt;threshold&gt;[:&lt;for&gt;] - Add a rule, e.g. cpu_percent&gt;80:10m (admin only)
+• /alert_mute &lt;id&gt; &lt;duration&gt; - Mute a rule, e.g. 2h, or "off" (admin only)
+• /alert_delete &lt;id&gt; - Delete a rule (admin only)
+
+<b>⚙️ Settings</b>
+• /settings - View and edit your preferences
+• /settings &lt;key&gt; &lt;value&gt; - Set one value (timezone, unit, language, interval, silent)
+
 <b>💡 Tips:</b>
 • Intervals are in minutes
+• Leave out the interval to use your default from /settings
 • Use quotes for messages with spaces
 • Use /reminder_list to get reminder IDs`
 		msg := tgbotapi.NewMessage(message.Chat.ID, text)
@@ -74,23 +191,129 @@ func (h *Handler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 		text, err = h.monitor.GetSystemStats()
 
 	case "temp":
-		text, err = h.monitor.GetTemperature()
+		var settings *storage.Settings
+		settings, err = h.db.GetSettings(message.From.ID, message.Chat.ID)
+		if err == nil {
+			text, err = h.monitor.GetTemperature(settings.TemperatureUnit)
+		}
+
+	case "power":
+		text, err = h.monitor.GetPowerStatus()
+
+	case "hw":
+		text, err = h.monitor.GetHardwareInfo()
+
+	case "mem":
+		text, err = h.monitor.GetMemoryDetails()
 
 	case "uptime":
 		text, err = h.monitor.GetUptime()
 
 	case "top":
-		text, err = h.monitor.GetTopProcesses()
+		if err = h.handleTop(bot, message); err == nil {
+			return
+		}
 
 	case "disk":
-		text, err = h.monitor.GetDiskUsage()
+		text, err = h.diskUsage()
 
 	case "network_details":
 		text, err = h.monitor.GetNetworkDetails()
-		// TODO: Add admin check
+
+	case "kill":
+		if !h.isAdmin(message.From.ID) {
+			err = errAdminOnly
+		} else if err = h.handleKill(bot, message); err == nil {
+			return
+		}
+
+	case "renice":
+		if !h.isAdmin(message.From.ID) {
+			err = errAdminOnly
+		} else if err = h.handleRenice(bot, message); err == nil {
+			return
+		}
+
+	case "probes":
+		text, err = h.listProbes()
+
+	case "watch":
+		text, err = h.handleWatch(message)
+
+	case "service":
+		if err = h.handleService(bot, message); err == nil {
+			return
+		}
+
+	case "gpio":
+		text, err = h.handleGPIO(bot, message)
+
+	case "sensors":
+		text, err = h.listSensors()
+
+	case "logs":
+		if err = h.handleLogs(bot, message); err == nil {
+			return
+		}
+
+	case "docker":
+		if err = h.handleDocker(bot, message); err == nil {
+			return
+		}
+
+	case "updates":
+		if err = h.handleUpdates(bot, message); err == nil {
+			return
+		}
+
+	case "upgrade":
+		if err = h.handleUpgrade(bot, message); err == nil {
+			return
+		}
+
 	case "reboot":
-		text, err = h.monitor.RebootSystem()
-		
+		if !h.isAdmin(message.From.ID) {
+			err = errAdminOnly
+		} else {
+			text, err = h.monitor.RebootSystem()
+		}
+
+	case "storage":
+		if !h.isAdmin(message.From.ID) {
+			err = errAdminOnly
+		} else {
+			text, err = h.storageReport()
+		}
+
+	case "alert_add":
+		if !h.isAdmin(message.From.ID) {
+			err = errAdminOnly
+		} else {
+			text, err = h.addAlertRule(message.CommandArguments())
+		}
+
+	case "alert_list":
+		text = h.listAlertRules()
+
+	case "alert_mute":
+		if !h.isAdmin(message.From.ID) {
+			err = errAdminOnly
+		} else {
+			text, err = h.muteAlertRule(message.CommandArguments())
+		}
+
+	case "alert_delete":
+		if !h.isAdmin(message.From.ID) {
+			err = errAdminOnly
+		} else {
+			text, err = h.deleteAlertRule(message.CommandArguments())
+		}
+
+	case "settings":
+		if err = h.handleSettings(bot, message); err == nil {
+			return
+		}
+
 	case "reminder_create":
 		var reminderID int64
 		args := strings.TrimSpace(message.CommandArguments())
@@ -99,18 +322,24 @@ func (h *Handler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 		} else {
 			// Split only on first space to keep message intact
 			parts := strings.SplitN(args, " ", 2)
-			if len(parts) < 2 {
-				err = fmt.Errorf("not enough arguments. Usage: /reminder_create <interval> <message>")
-			} else {
-				interval, parseErr := strconv.Atoi(parts[0])
-				if parseErr != nil {
-					err = fmt.Errorf("invalid interval: %v", parseErr)
-				} else {
-					reminderMessage := parts[1]
-					reminderID, err = h.reminder.CreateReminder(message.Chat.ID, interval, reminderMessage)
-					if err == nil {
-						text = fmt.Sprintf("Reminder created successfully! ID: %d", reminderID)
-					}
+			interval, parseErr := strconv.Atoi(parts[0])
+			if parseErr != nil {
+				// No interval given, so the whole text is the message and
+				// the user's default interval applies
+				var settings *storage.Settings
+				settings, err = h.db.GetSettings(message.From.ID, message.Chat.ID)
+				if err == nil {
+					interval = settings.DefaultInterval
+					parts = []string{"", args}
+				}
+			}
+			if err == nil && len(parts) < 2 {
+				err = fmt.Errorf("not enough arguments. Usage: /reminder_create [interval] <message>")
+			} else if err == nil {
+				reminderMessage := parts[1]
+				reminderID, err = h.reminder.CreateReminder(message.Chat.ID, interval, reminderMessage)
+				if err == nil {
+					text = fmt.Sprintf("Reminder created successfully! ID: %d", reminderID)
 				}
 			}
 		}
@@ -118,10 +347,20 @@ func (h *Handler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 	case "reminder_list":
 		reminders, err := h.reminder.ListReminders(message.Chat.ID)
 		if err == nil {
+			settings, settingsErr := h.db.GetSettings(message.From.ID, message.Chat.ID)
+			if settingsErr != nil {
+				settings = storage.DefaultSettings(message.From.ID, message.Chat.ID)
+			}
+			loc := settings.Location()
+
 			text = "Active Reminders:\n"
 			for _, reminder := range reminders {
-				text += fmt.Sprintf("ID: %d\nInterval: %d minutes\nMessage: %s\n\n",
+				text += fmt.Sprintf("ID: %d\nInterval: %d minutes\nMessage: %s\n",
 					reminder.ID, reminder.Interval, reminder.Message)
+				if reminder.NextTrigger.Valid {
+					text += fmt.Sprintf("Next: %s\n", reminder.NextTrigger.Time.In(loc).Format("Jan 2 15:04 MST"))
+				}
+				text += "\n"
 			}
 		}
 	case "reminder_pause":
@@ -155,3 +394,71 @@ func (h *Handler) HandleCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 	msg := tgbotapi.NewMessage(message.Chat.ID, text)
 	bot.Send(msg)
 }
+
+// HandleCallback dispatches inline keyboard presses. Callback data has the
+// form "<feature>:<payload>".
+func (h *Handler) HandleCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) {
+	feature, payload, _ := strings.Cut(query.Data, ":")
+
+	var err error
+	switch feature {
+	case "settings":
+		err = h.handleSettingsCallback(bot, query, payload)
+	case "proc":
+		err = h.handleProcessCallback(bot, query, payload)
+	case "upgrade":
+		err = h.handleUpgradeCallback(bot, query, payload)
+	default:
+		err = fmt.Errorf("unknown action")
+	}
+
+	// Always answer so the client stops showing a progress indicator
+	answer := tgbotapi.NewCallback(query.ID, "")
+	if err != nil {
+		answer.Text = "Error: " + err.Error()
+	}
+	bot.Request(answer)
+}
+
+// diskUsage reports disk usage with a fill projection from the last week
+// of stored samples.
+func (h *Handler) diskUsage() (string, error) {
+	stored, err := h.db.GetMetricSamples(diskUsedMetric, time.Now().Add(-7*24*time.Hour))
+	if err != nil {
+		return "", err
+	}
+
+	history := make(map[string][]monitor.Sample)
+	for mountpoint, samples := range stored {
+		for _, s := range samples {
+			history[mountpoint] = append(history[mountpoint], monitor.Sample{At: s.RecordedAt, Value: s.Value})
+		}
+	}
+
+	return h.monitor.GetDiskUsage(history)
+}
+
+// storageReport lists row counts per table and the database file size.
+func (h *Handler) storageReport() (string, error) {
+	tables, err := h.db.TableStats()
+	if err != nil {
+		return "", err
+	}
+	dbSize, walSize, err := h.db.FileSizes()
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString("Storage:\n")
+	result.WriteString(fmt.Sprintf("Database: %.1f KB", float64(dbSize)/1024))
+	if walSize > 0 {
+		result.WriteString(fmt.Sprintf(" (+ %.1f KB WAL)", float64(walSize)/1024))
+	}
+	result.WriteString("\n\nTables:\n")
+	for _, t := range tables {
+		result.WriteString(fmt.Sprintf("%s: %d rows\n", t.Name, t.Rows))
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,281 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/storage"
+)
+
+// purgeInterval is how often retention policies are enforced.
+const purgeInterval = 24 * time.Hour
+
+// sampleInterval is how often trend samples, such as disk usage, are
+// stored.
+const sampleInterval = time.Hour
+
+// diskUsedMetric is the metric_samples name for used bytes per mountpoint.
+const diskUsedMetric = "disk_used_bytes"
+
+// addressInterval is how often interface addresses and default routes are
+// checked for changes.
+const addressInterval = 15 * time.Second
+
+// maxPendingNotices bounds the address change notices kept for resending
+// while Telegram cannot be reached. The oldest are dropped first.
+const maxPendingNotices = 50
+
+// startJobs launches the bot's background jobs. They stop when Stop
+// cancels b.ctx.
+func (b *Bot) startJobs() {
+	b.goJob(b.purgeLoop)
+	if b.backupEvery > 0 {
+		b.goJob(b.backupLoop)
+	}
+	b.goJob(func() { b.monitor.Run(b.ctx) })
+	b.goJob(func() { b.alerts.Run(b.ctx) })
+	b.goJob(func() { b.probes.Run(b.ctx) })
+	b.goJob(b.addressLoop)
+	b.goJob(b.sampleLoop)
+	if b.sensorServer != nil {
+		b.goJob(b.serveSensors)
+	}
+	if b.updateDigest != nil {
+		b.goJob(b.updateDigestLoop)
+	}
+}
+
+// goJob runs job in a goroutine that Stop waits for.
+func (b *Bot) goJob(job func()) {
+	b.jobs.Add(1)
+	go func() {
+		defer b.jobs.Done()
+		job()
+	}()
+}
+
+// serveSensors runs the sensor endpoint until Stop.
+func (b *Bot) serveSensors() {
+	if err := b.sensorServer.Run(b.ctx); err != nil {
+		log.Printf("Sensor endpoint stopped: %v", err)
+	}
+}
+
+// sampleLoop stores trend samples hourly. /disk uses the disk usage
+// history to project when each filesystem fills up.
+func (b *Bot) sampleLoop() {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		b.recordSamples()
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Bot) recordSamples() {
+	usages, err := b.monitor.DiskUsages()
+	if err != nil {
+		log.Printf("Error sampling disk usage: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, d := range usages {
+		if err := b.db.AddMetricSample(d.Mountpoint, diskUsedMetric, float64(d.Usage.Used), now); err != nil {
+			log.Printf("Error storing disk usage sample: %v", err)
+		}
+	}
+}
+
+// notice is an address change notice still to be delivered to a chat.
+type notice struct {
+	chatID int64
+	text   string
+	at     time.Time
+}
+
+// addressLoop tells the admins when an address or default route changes,
+// e.g. after a new DHCP lease, so they can still reach the Pi over SSH.
+// The watcher reports each change once, so notices that could not be sent,
+// typically because the network was still coming up, are resent on the
+// following ticks until they get through.
+func (b *Bot) addressLoop() {
+	ticker := time.NewTicker(addressInterval)
+	defer ticker.Stop()
+
+	var pending []notice
+	for {
+		events, err := b.addresses.Events()
+		if err != nil {
+			log.Printf("Error checking network addresses: %v", err)
+		}
+		now := time.Now()
+		for _, text := range events {
+			for _, id := range b.adminUsers {
+				pending = append(pending, notice{chatID: id, text: text, at: now})
+			}
+		}
+		pending = b.deliverNotices(pending, now)
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverNotices sends notices in order and returns the ones to retry.
+// After a failed send the rest of that chat's notices wait too, so they
+// arrive in the order the changes happened.
+func (b *Bot) deliverNotices(notices []notice, now time.Time) []notice {
+	var failed []notice
+	blocked := make(map[int64]bool)
+	for _, n := range notices {
+		if !blocked[n.chatID] {
+			text := n.text
+			if now.Sub(n.at) >= addressInterval {
+				text += fmt.Sprintf("\n(detected at %s)", n.at.Format("15:04:05"))
+			}
+			if err := b.notify([]int64{n.chatID}, text); err == nil {
+				continue
+			}
+			blocked[n.chatID] = true
+		}
+		failed = append(failed, n)
+	}
+	if len(failed) > maxPendingNotices {
+		log.Printf("Dropping %d undelivered address change notices", len(failed)-maxPendingNotices)
+		failed = failed[len(failed)-maxPendingNotices:]
+	}
+	return failed
+}
+
+// purgeLoop enforces retention policies shortly after startup and then once
+// a day.
+func (b *Bot) purgeLoop() {
+	timer := time.NewTimer(time.Minute)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-timer.C:
+			b.purge()
+			timer.Reset(purgeInterval)
+		}
+	}
+}
+
+// backupLoop writes a backup to the backup directory every backupEvery,
+// counting from the newest backup already there, and keeps the newest
+// backupKeep. Recovery restores from these when the database is damaged.
+func (b *Bot) backupLoop() {
+	wait := time.Minute
+	if last := storage.LatestBackupTime(b.backupDir, b.dbPath); !last.IsZero() {
+		if due := time.Until(last.Add(b.backupEvery)); due > wait {
+			wait = due
+		}
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-timer.C:
+			b.backup()
+			timer.Reset(b.backupEvery)
+		}
+	}
+}
+
+func (b *Bot) backup() {
+	path := filepath.Join(b.backupDir, storage.BackupFileName(b.dbPath, time.Now()))
+	if err := b.db.Backup(path); err != nil {
+		log.Printf("Error writing scheduled backup: %v", err)
+		return
+	}
+	if _, err := storage.PruneBackups(b.backupDir, b.dbPath, b.backupKeep); err != nil {
+		log.Printf("Error pruning old backups: %v", err)
+	}
+}
+
+func (b *Bot) purge() {
+	if len(b.retention) == 0 {
+		return
+	}
+
+	results, err := b.db.Purge(b.retention)
+	if err != nil {
+		log.Printf("Error purging old data: %v", err)
+		return
+	}
+
+	var total int64
+	var report strings.Builder
+	report.WriteString("🧹 Daily purge removed old rows:\n")
+	for _, r := range results {
+		total += r.Deleted
+		if r.Deleted > 0 {
+			report.WriteString(fmt.Sprintf("• %s: %d rows\n", r.Table, r.Deleted))
+		}
+	}
+
+	log.Printf("Purge completed. Removed %d rows", total)
+	if total > 0 {
+		b.notifyAdmins(report.String())
+	}
+}
+
+// updateDigestLoop sends the admins a weekly list of pending package
+// updates, at the time set by UPDATE_DIGEST.
+func (b *Bot) updateDigestLoop() {
+	for {
+		timer := time.NewTimer(time.Until(b.updateDigest.Next(time.Now())))
+		select {
+		case <-b.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if text := b.updateDigestText(); text != "" {
+			b.notifyAdmins(text)
+		}
+	}
+}
+
+// updateDigestText refreshes the package lists when sudo allows it and
+// describes the pending updates, or returns "" when there are none.
+func (b *Bot) updateDigestText() string {
+	if err := b.updates.Refresh(b.ctx); err != nil {
+		log.Printf("Update digest uses the existing package lists: %v", err)
+	}
+
+	packages, err := b.updates.Upgradable(b.ctx)
+	if err != nil {
+		log.Printf("Error checking for package updates: %v", err)
+		return ""
+	}
+	if required, _ := b.updates.RebootRequired(); len(packages) == 0 && !required {
+		return ""
+	}
+
+	text := "🗓 Weekly update digest\n\n" + formatUpdates(b.updates, packages)
+	if len(packages) > 0 {
+		text += "\n\nRun /upgrade to install them."
+	}
+	return text
+}
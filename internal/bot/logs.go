@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mypibot-go/internal/logs"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultLogLines = 20
+	maxLogLines     = 500
+
+	// maxMessageLength is Telegram's limit for message text. Longer log
+	// output is sent as a file.
+	maxMessageLength = 4096
+)
+
+const logsUsage = "Usage: /logs <unit|file|kernel> [n] [grep], e.g. /logs nginx 50 error"
+
+// loadLogRules parses the configured log alert rules.
+func loadLogRules(specs []string) ([]*logs.Rule, error) {
+	var rules []*logs.Rule
+	for _, spec := range specs {
+		rule, err := logs.ParseRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// handleLogs handles /logs <source> [n] [grep]. Output that does not fit
+// in a message is sent as a document.
+func (h *Handler) handleLogs(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 {
+		var sources []string
+		for _, s := range h.logs.Sources() {
+			sources = append(sources, s.String())
+		}
+		if len(sources) == 0 {
+			return fmt.Errorf("no log sources are allowed. Set LOG_SOURCES in .env")
+		}
+		return h.reply(bot, message.Chat.ID, logsUsage+"\nSources: "+strings.Join(sources, ", "))
+	}
+
+	source, n := args[0], defaultLogLines
+	rest := args[1:]
+	if len(rest) > 0 {
+		if parsed, err := strconv.Atoi(rest[0]); err == nil {
+			if parsed < 1 || parsed > maxLogLines {
+				return fmt.Errorf("number of lines must be between 1 and %d", maxLogLines)
+			}
+			n, rest = parsed, rest[1:]
+		}
+	}
+	var filter *regexp.Regexp
+	if len(rest) > 0 {
+		filter = logs.Grep(strings.Join(rest, " "))
+	}
+
+	lines, err := h.logs.Tail(context.Background(), source, n, filter)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return h.reply(bot, message.Chat.ID, "No matching log lines.")
+	}
+
+	name := strings.NewReplacer("/", "_", ".", "_").Replace(strings.TrimPrefix(logs.ParseSource(source).String(), "/"))
+	return h.replyOrDocument(bot, message.Chat.ID, strings.Join(lines, "\n"), name+".log",
+		fmt.Sprintf("Last %d lines of %s", len(lines), logs.ParseSource(source)))
+}
+
+// reply sends a plain text message.
+func (h *Handler) reply(bot *tgbotapi.BotAPI, chatID int64, text string) error {
+	_, err := bot.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+// replyOrDocument sends text as a message, or as a file with the given
+// name and caption when it is too long for one.
+func (h *Handler) replyOrDocument(bot *tgbotapi.BotAPI, chatID int64, text, name, caption string) error {
+	if len(text) <= maxMessageLength {
+		return h.reply(bot, chatID, text)
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  name,
+		Bytes: []byte(text + "\n"),
+	})
+	doc.Caption = caption
+	_, err := bot.Send(doc)
+	return err
+}
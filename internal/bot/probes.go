@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/probe"
+)
+
+// loadProbes parses the configured probe specs.
+func loadProbes(specs []string, timeout time.Duration) ([]*probe.Probe, error) {
+	var probes []*probe.Probe
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		p, err := probe.ParseProbe(spec, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("invalid probe %q: duplicate name %q", spec, p.Name)
+		}
+		seen[p.Name] = true
+		probes = append(probes, p)
+	}
+	return probes, nil
+}
+
+// listProbes handles /probes: current state, latency and uptime per probe.
+func (h *Handler) listProbes() (string, error) {
+	probes := h.probes.Probes()
+	if len(probes) == 0 {
+		return "No probes configured. Set PROBES in .env.", nil
+	}
+
+	now := time.Now()
+	var result strings.Builder
+	result.WriteString("Probes:\n")
+	for _, p := range probes {
+		last, ok := h.probes.Last(p.Name)
+		icon := "⚪"
+		if ok && last.Up {
+			icon = "🟢"
+		} else if ok {
+			icon = "🔴"
+		}
+		result.WriteString(fmt.Sprintf("\n%s %s (%s %s, every %s)\n", icon, p.Name, p.Type, p.Target, p.Interval))
+
+		switch {
+		case !ok:
+			result.WriteString("  Not checked yet\n")
+		case last.Up:
+			result.WriteString(fmt.Sprintf("  Latency: %d ms\n", last.Latency.Milliseconds()))
+		default:
+			result.WriteString(fmt.Sprintf("  Error: %s\n", last.Err))
+		}
+
+		day, err := h.db.GetProbeStats(p.Name, now.Add(-24*time.Hour))
+		if err != nil {
+			return "", err
+		}
+		week, err := h.db.GetProbeStats(p.Name, now.Add(-7*24*time.Hour))
+		if err != nil {
+			return "", err
+		}
+		if day.Checks > 0 {
+			result.WriteString(fmt.Sprintf("  Uptime: %.2f%% (24h), %.2f%% (7d)\n",
+				day.UptimePercent(), week.UptimePercent()))
+		}
+		if day.Up > 0 {
+			result.WriteString(fmt.Sprintf("  Avg latency (24h): %d ms\n", day.AvgLatency.Milliseconds()))
+		}
+	}
+
+	return result.String(), nil
+}
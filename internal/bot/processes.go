@@ -0,0 +1,221 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"mypibot-go/internal/monitor"
+	"mypibot-go/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxTopProcesses bounds /top's n. Longer replies are sent as a file.
+const maxTopProcesses = 30
+
+// parseTopArgs parses /top [cpu|mem|io] [n] [filter] [group]. The words
+// may come in any order; anything that is not a sort key, a number or
+// "group" is the filter.
+func parseTopArgs(args string) (monitor.TopOptions, error) {
+	var opts monitor.TopOptions
+	var filter []string
+
+	for _, word := range strings.Fields(args) {
+		switch strings.ToLower(word) {
+		case monitor.SortCPU, monitor.SortMem, monitor.SortIO:
+			opts.SortBy = strings.ToLower(word)
+			continue
+		case "group":
+			opts.Group = true
+			continue
+		}
+
+		if n, err := strconv.Atoi(word); err == nil {
+			if n < 1 || n > maxTopProcesses {
+				return opts, fmt.Errorf("number of processes must be between 1 and %d", maxTopProcesses)
+			}
+			opts.Limit = n
+			continue
+		}
+		filter = append(filter, word)
+	}
+
+	opts.Filter = strings.Join(filter, " ")
+	return opts, nil
+}
+
+// handleTop handles /top. Sampling CPU usage takes monitor.TopWindow, so
+// it runs off the update loop and the reply is sent when it is ready.
+func (h *Handler) handleTop(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	opts, err := parseTopArgs(message.CommandArguments())
+	if err != nil {
+		return err
+	}
+
+	chatID := message.Chat.ID
+	h.background(func() {
+		text, err := h.monitor.GetTopProcesses(opts)
+		if err != nil {
+			text = "Error: " + err.Error()
+		}
+		if err := h.replyOrDocument(bot, chatID, strings.TrimRight(text, "\n"), "top.txt", "Top processes"); err != nil {
+			log.Printf("Error sending /top: %v", err)
+		}
+	})
+	return nil
+}
+
+// handleKill handles /kill <pid> [signal] by asking for confirmation.
+func (h *Handler) handleKill(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: /kill <pid> [signal], e.g. /kill 1234 or /kill 1234 KILL")
+	}
+
+	signal := "TERM"
+	if len(args) == 2 {
+		var err error
+		if signal, err = monitor.ParseSignal(args[1]); err != nil {
+			return err
+		}
+	}
+
+	return h.confirmProcessAction(bot, message.Chat.ID, args[0], "kill", signal,
+		"Send SIG"+signal+" to this process?")
+}
+
+// handleRenice handles /renice <pid> <n> by asking for confirmation.
+func (h *Handler) handleRenice(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 2 {
+		return fmt.Errorf("usage: /renice <pid> <n>, where n is from -20 (highest priority) to 19 (lowest)")
+	}
+
+	nice, err := strconv.Atoi(args[1])
+	if err != nil || nice < -20 || nice > 19 {
+		return fmt.Errorf("nice value must be a number between -20 and 19")
+	}
+
+	return h.confirmProcessAction(bot, message.Chat.ID, args[0], "renice", args[1],
+		fmt.Sprintf("Change the nice value of this process to %d?", nice))
+}
+
+// confirmProcessAction shows the target process and Confirm/Cancel
+// buttons. The callback data carries the process's create time so a PID
+// reused in the meantime is not touched.
+func (h *Handler) confirmProcessAction(bot *tgbotapi.BotAPI, chatID int64, pidArg, action, value, question string) error {
+	pid, err := strconv.ParseInt(pidArg, 10, 32)
+	if err != nil || pid <= 0 {
+		return fmt.Errorf("invalid PID %q", pidArg)
+	}
+
+	p, err := h.monitor.Process(int32(pid))
+	if err != nil {
+		return err
+	}
+	if err := monitor.CheckProtected(p); err != nil {
+		return err
+	}
+
+	data := fmt.Sprintf("proc:%s:%d:%s:%d", action, p.PID, value, p.CreateTime)
+	msg := tgbotapi.NewMessage(chatID, formatProcessDetails(p)+"\n\n"+question)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Confirm", data),
+			tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", "proc:cancel"),
+		),
+	)
+	_, err = bot.Send(msg)
+	return err
+}
+
+func formatProcessDetails(p *monitor.ProcessDetails) string {
+	text := fmt.Sprintf("%s (PID: %d)\nUser: %s\nNice: %d", p.Name, p.PID, p.User, p.Nice)
+	if p.Cmdline != "" {
+		text += "\nCommand: " + p.Cmdline
+	}
+	return text
+}
+
+// handleProcessCallback runs a confirmed /kill or /renice. The payload is
+// "<action>:<pid>:<value>:<create time>" or "cancel".
+func (h *Handler) handleProcessCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery, payload string) error {
+	if query.Message == nil {
+		return nil
+	}
+	if !h.isAdmin(query.From.ID) {
+		return errAdminOnly
+	}
+
+	chatID, messageID := query.Message.Chat.ID, query.Message.MessageID
+	if payload == "cancel" {
+		_, err := bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, query.Message.Text+"\n\nCancelled."))
+		return err
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid process action")
+	}
+	action, value := parts[0], parts[2]
+	pid, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid process action")
+	}
+	createTime, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid process action")
+	}
+
+	// Check again: the process may have exited, or its PID been reused
+	p, err := h.monitor.Process(int32(pid))
+	if err == nil && p.CreateTime != createTime {
+		err = fmt.Errorf("process %d has exited", pid)
+	}
+	if err == nil {
+		err = monitor.CheckProtected(p)
+	}
+
+	target := fmt.Sprintf("pid %d", pid)
+	var done string
+	if err == nil {
+		target = fmt.Sprintf("pid %d (%s, %s)", pid, p.Name, p.User)
+		switch action {
+		case "kill":
+			target += " SIG" + value
+			err = h.monitor.SignalProcess(p.PID, value)
+			done = fmt.Sprintf("✅ Sent SIG%s to %s (PID: %d).", value, p.Name, pid)
+		case "renice":
+			var nice int
+			if nice, err = strconv.Atoi(value); err == nil {
+				target += " nice " + value
+				err = h.monitor.ReniceProcess(p.PID, nice)
+				done = fmt.Sprintf("✅ Set the nice value of %s (PID: %d) to %d.", p.Name, pid, nice)
+			}
+		default:
+			err = fmt.Errorf("unknown process action %q", action)
+		}
+	}
+
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+		done = "❌ " + err.Error()
+	}
+	if auditErr := h.db.AddAuditEntry(&storage.AuditEntry{
+		UserID: query.From.ID,
+		Action: action,
+		Target: target,
+		Result: result,
+	}); auditErr != nil {
+		log.Printf("Error recording audit entry: %v", auditErr)
+	}
+
+	_, sendErr := bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, query.Message.Text+"\n\n"+done))
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
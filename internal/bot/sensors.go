@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/sensor"
+	"mypibot-go/internal/storage"
+)
+
+// loadSensors returns a sensor registry seeded with the newest stored
+// readings, so /sensors and alerts have values right after a restart.
+func loadSensors(db *storage.Database, staleAfter time.Duration) (*sensor.Registry, error) {
+	stored, err := db.LatestSensorReadings()
+	if err != nil {
+		return nil, err
+	}
+
+	var readings []sensor.Reading
+	for _, r := range stored {
+		name, metric, ok := sensor.ParseMetricName(r.Metric)
+		if !ok {
+			continue
+		}
+		readings = append(readings, sensor.Reading{
+			Sensor: name,
+			Metric: metric,
+			Value:  r.Value,
+			Unit:   r.Unit,
+			At:     r.RecordedAt,
+		})
+	}
+
+	registry := sensor.NewRegistry(db, staleAfter)
+	registry.Restore(readings)
+	return registry, nil
+}
+
+// listSensors handles /sensors: the latest reading of every sensor metric
+// with its range over the last 24 hours.
+func (h *Handler) listSensors() (string, error) {
+	readings := h.sensors.Latest()
+	if len(readings) == 0 {
+		return "No sensor readings yet. Set SENSOR_LISTEN and SENSOR_TOKEN in .env and point your sensors at /readings.", nil
+	}
+
+	ranges, err := h.db.GetSensorRanges(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	var result strings.Builder
+	result.WriteString("Sensors:\n")
+	current := ""
+	for _, r := range readings {
+		if r.Sensor != current {
+			current = r.Sensor
+			result.WriteString(fmt.Sprintf("\n📡 %s\n", r.Sensor))
+		}
+
+		result.WriteString(fmt.Sprintf("  %s: %s", r.Metric, formatReading(r.Value, r.Unit)))
+		if rng, ok := ranges[r.Name()]; ok && rng.Count > 1 {
+			result.WriteString(fmt.Sprintf(" (24h: %s–%s, avg %s)",
+				formatReading(rng.Min, ""), formatReading(rng.Max, ""), formatReading(rng.Avg, r.Unit)))
+		}
+
+		age := now.Sub(r.At)
+		switch {
+		case h.sensors.Stale(r):
+			result.WriteString(fmt.Sprintf("\n    ⚠️ silent for %s", formatAge(age)))
+		case age < time.Minute:
+			result.WriteString(", just now")
+		default:
+			result.WriteString(fmt.Sprintf(", %s ago", formatAge(age)))
+		}
+		result.WriteString("\n")
+	}
+
+	return result.String(), nil
+}
+
+// formatReading renders a value with up to two decimals and its unit.
+func formatReading(value float64, unit string) string {
+	text := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+	if text == "-0" {
+		text = "0"
+	}
+	if unit != "" {
+		text += " " + unit
+	}
+	return text
+}
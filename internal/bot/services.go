@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/storage"
+	"mypibot-go/internal/systemd"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const serviceUsage = `Usage:
+/service - list allowed units
+/service status <unit>
+/service start|stop|restart <unit>`
+
+// handleService handles /service [status|start|stop|restart <unit>].
+func (h *Handler) handleService(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	args := strings.Fields(message.CommandArguments())
+	if len(h.services.Units()) == 0 {
+		return h.reply(bot, message.Chat.ID, "No units are allowed. Set SERVICE_UNITS in .env.")
+	}
+	if len(args) == 0 {
+		return h.reply(bot, message.Chat.ID, h.listServices())
+	}
+	if len(args) != 2 {
+		return errors.New(serviceUsage)
+	}
+
+	action, unit := args[0], args[1]
+	switch action {
+	case "status":
+		status, err := h.services.Status(context.Background(), unit)
+		if err != nil {
+			return err
+		}
+		return h.reply(bot, message.Chat.ID, formatUnitStatus(status))
+	case systemd.ActionStart, systemd.ActionStop, systemd.ActionRestart:
+	default:
+		return errors.New(serviceUsage)
+	}
+
+	if !h.isAdmin(message.From.ID) {
+		return errAdminOnly
+	}
+	if !h.services.Allowed(unit) {
+		return fmt.Errorf("%s is not in SERVICE_UNITS", systemd.NormalizeUnit(unit))
+	}
+
+	sent, err := bot.Send(tgbotapi.NewMessage(message.Chat.ID,
+		fmt.Sprintf("⏳ Running systemctl %s %s...", action, systemd.NormalizeUnit(unit))))
+	if err != nil {
+		return err
+	}
+	// A slow unit can take up to a minute and a half; other chats are
+	// answered meanwhile
+	h.background(func() {
+		h.controlService(bot, sent.Chat.ID, sent.MessageID, message.From.ID, action, unit)
+	})
+	return nil
+}
+
+// controlService runs a start, stop or restart and edits the progress
+// message with the result.
+func (h *Handler) controlService(bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64, action, unit string) {
+	ctx := context.Background()
+	err := h.services.Control(ctx, action, unit)
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	if auditErr := h.db.AddAuditEntry(&storage.AuditEntry{
+		UserID: userID,
+		Action: "service " + action,
+		Target: systemd.NormalizeUnit(unit),
+		Result: result,
+	}); auditErr != nil {
+		log.Printf("Error recording audit entry: %v", auditErr)
+	}
+
+	var text string
+	if err != nil {
+		text = "Error: " + err.Error()
+	} else if status, statusErr := h.services.Status(ctx, unit); statusErr != nil {
+		text = fmt.Sprintf("✅ systemctl %s %s done, but its status could not be read: %v", action, systemd.NormalizeUnit(unit), statusErr)
+	} else {
+		text = formatUnitStatus(status)
+	}
+	if _, err := bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, text)); err != nil {
+		log.Printf("Error updating service result: %v", err)
+	}
+}
+
+// listServices shows the state of every allowlisted unit.
+func (h *Handler) listServices() string {
+	var result strings.Builder
+	result.WriteString("Services:\n")
+	for _, unit := range h.services.Units() {
+		status, err := h.services.Status(context.Background(), unit)
+		if err != nil {
+			result.WriteString(fmt.Sprintf("⚠️ %s: %v\n", unit, err))
+			continue
+		}
+		result.WriteString(fmt.Sprintf("%s %s: %s (%s)\n", unitIcon(status), unit, status.ActiveState, status.SubState))
+	}
+	result.WriteString("\nUse /service status <unit> for details.")
+	return result.String()
+}
+
+func unitIcon(s *systemd.UnitStatus) string {
+	switch s.ActiveState {
+	case "active":
+		return "🟢"
+	case "failed":
+		return "🔴"
+	case "activating", "deactivating", "reloading":
+		return "🟡"
+	}
+	return "⚪"
+}
+
+// formatUnitStatus renders a compact status card.
+func formatUnitStatus(s *systemd.UnitStatus) string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%s %s\n", unitIcon(s), s.ID))
+	if s.Description != "" {
+		result.WriteString(s.Description + "\n")
+	}
+	if s.LoadState != "loaded" {
+		result.WriteString(fmt.Sprintf("Load: %s\n", s.LoadState))
+	}
+	result.WriteString(fmt.Sprintf("State: %s (%s)", s.ActiveState, s.SubState))
+	if s.Result != "" && s.Result != "success" {
+		result.WriteString(fmt.Sprintf(", result: %s", s.Result))
+	}
+	result.WriteString("\n")
+	if !s.Since.IsZero() {
+		result.WriteString(fmt.Sprintf("Since: %s (%s ago)\n",
+			s.Since.Format("Jan 2 15:04"), formatAge(time.Since(s.Since))))
+	}
+	if s.MainPID > 0 {
+		result.WriteString(fmt.Sprintf("Main PID: %d\n", s.MainPID))
+	}
+	if s.HasMemory {
+		result.WriteString(fmt.Sprintf("Memory: %.1f MB\n", float64(s.Memory)/1024/1024))
+	}
+	result.WriteString(fmt.Sprintf("Restarts: %d\n", s.Restarts))
+	return result.String()
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"mypibot-go/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Values the inline keyboard cycles through. Other values can still be set
+// with "/settings <key> <value>".
+var (
+	timezonePresets = []string{"Local", "UTC", "Asia/Kolkata", "Europe/London", "America/New_York"}
+	intervalPresets = []int{30, 60, 90, 120, 180, 240, 360}
+)
+
+const settingsUsage = `Usage: /settings [<key> <value>]
+Keys: timezone (e.g. Asia/Kolkata), unit (C|F), language (en|ml), interval (minutes), silent (on|off)`
+
+// handleSettings shows the caller's settings with an editing keyboard, or
+// sets a single value when called as "/settings <key> <value>".
+func (h *Handler) handleSettings(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	settings, err := h.db.GetSettings(message.From.ID, message.Chat.ID)
+	if err != nil {
+		return err
+	}
+
+	if args := strings.Fields(message.CommandArguments()); len(args) > 0 {
+		if len(args) != 2 {
+			return errors.New(settingsUsage)
+		}
+		if err := settings.Set(strings.ToLower(args[0]), args[1]); err != nil {
+			return err
+		}
+		if err := h.db.SaveSettings(settings); err != nil {
+			return err
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, formatSettings(settings))
+	msg.ReplyMarkup = settingsKeyboard(settings)
+	_, err = bot.Send(msg)
+	return err
+}
+
+// handleSettingsCallback advances the setting named in the callback data to
+// its next preset value and updates the settings message in place.
+func (h *Handler) handleSettingsCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery, key string) error {
+	if query.Message == nil {
+		return nil
+	}
+
+	settings, err := h.db.GetSettings(query.From.ID, query.Message.Chat.ID)
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case storage.SettingTemperatureUnit:
+		if settings.TemperatureUnit == "C" {
+			settings.TemperatureUnit = "F"
+		} else {
+			settings.TemperatureUnit = "C"
+		}
+	case storage.SettingSilent:
+		settings.SilentNotifications = !settings.SilentNotifications
+	case storage.SettingDefaultInterval:
+		settings.DefaultInterval = nextInt(intervalPresets, settings.DefaultInterval)
+	case storage.SettingLanguage:
+		settings.Language = nextString(storage.SupportedLanguages, settings.Language)
+	case storage.SettingTimezone:
+		settings.Timezone = nextString(timezonePresets, settings.Timezone)
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	if err := h.db.SaveSettings(settings); err != nil {
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(
+		query.Message.Chat.ID,
+		query.Message.MessageID,
+		formatSettings(settings),
+		settingsKeyboard(settings),
+	)
+	_, err = bot.Send(edit)
+	return err
+}
+
+func formatSettings(s *storage.Settings) string {
+	silent := "off"
+	if s.SilentNotifications {
+		silent = "on"
+	}
+
+	return fmt.Sprintf("⚙️ Settings\n"+
+		"Time zone: %s\n"+
+		"Temperature unit: °%s\n"+
+		"Language: %s\n"+
+		"Default reminder interval: %d minutes\n"+
+		"Silent notifications: %s\n\n"+
+		"Tap a button to change a value, or use /settings <key> <value>.",
+		s.Timezone, s.TemperatureUnit, s.Language, s.DefaultInterval, silent)
+}
+
+func settingsKeyboard(s *storage.Settings) tgbotapi.InlineKeyboardMarkup {
+	silent := "off"
+	if s.SilentNotifications {
+		silent = "on"
+	}
+
+	button := func(label, key string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(label, "settings:"+key)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			button("🌡 °"+s.TemperatureUnit, storage.SettingTemperatureUnit),
+			button("🔕 Silent: "+silent, storage.SettingSilent),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			button(fmt.Sprintf("⏱ %d min", s.DefaultInterval), storage.SettingDefaultInterval),
+			button("🌐 "+s.Language, storage.SettingLanguage),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			button("🕒 "+s.Timezone, storage.SettingTimezone),
+		),
+	)
+}
+
+// nextString returns the value after current in values, wrapping around.
+func nextString(values []string, current string) string {
+	for i, v := range values {
+		if v == current {
+			return values[(i+1)%len(values)]
+		}
+	}
+	return values[0]
+}
+
+// nextInt returns the smallest value greater than current, wrapping around.
+func nextInt(values []int, current int) int {
+	for _, v := range values {
+		if v > current {
+			return v
+		}
+	}
+	return values[0]
+}
@@ -0,0 +1,285 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"mypibot-go/internal/apt"
+	"mypibot-go/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxUpdatesListed bounds the packages listed in /updates and the digest.
+const maxUpdatesListed = 40
+
+// upgradeEditInterval is how often the progress message of a running
+// upgrade is edited; Telegram rate limits edits.
+const upgradeEditInterval = 3 * time.Second
+
+// upgradeProgressLines is how many of apt-get's latest output lines the
+// progress message shows.
+const upgradeProgressLines = 8
+
+// refreshTimeout bounds /updates refresh; apt-get update can hang on an
+// unreachable mirror.
+const refreshTimeout = 10 * time.Minute
+
+// handleUpdates handles /updates [refresh]. Refreshing downloads the
+// package lists, so it runs off the update loop and edits its progress
+// message with the result.
+func (h *Handler) handleUpdates(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	switch args := strings.Fields(message.CommandArguments()); {
+	case len(args) == 0:
+		packages, err := h.updates.Upgradable(context.Background())
+		if err != nil {
+			return err
+		}
+		return h.reply(bot, message.Chat.ID, formatUpdates(h.updates, packages))
+	case len(args) == 1 && args[0] == "refresh":
+	default:
+		return fmt.Errorf("usage: /updates [refresh]")
+	}
+
+	if !h.isAdmin(message.From.ID) {
+		return errAdminOnly
+	}
+	sent, err := bot.Send(tgbotapi.NewMessage(message.Chat.ID, "⏳ Refreshing package lists..."))
+	if err != nil {
+		return err
+	}
+	h.background(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		var text string
+		if err := h.updates.Refresh(ctx); err != nil {
+			text = "Error: " + err.Error()
+		} else if packages, err := h.updates.Upgradable(ctx); err != nil {
+			text = "Error: " + err.Error()
+		} else {
+			text = formatUpdates(h.updates, packages)
+		}
+		if _, err := bot.Send(tgbotapi.NewEditMessageText(sent.Chat.ID, sent.MessageID, text)); err != nil {
+			log.Printf("Error updating package list refresh: %v", err)
+		}
+	})
+	return nil
+}
+
+// formatUpdates lists pending updates, security updates first, and whether
+// a reboot is pending.
+func formatUpdates(updates *apt.Manager, packages []apt.Package) string {
+	var result strings.Builder
+	if len(packages) == 0 {
+		result.WriteString("✅ All packages are up to date.\n")
+	} else {
+		security := 0
+		for _, p := range packages {
+			if p.Security() {
+				security++
+			}
+		}
+		result.WriteString(fmt.Sprintf("📦 %d upgradable packages", len(packages)))
+		if security > 0 {
+			result.WriteString(fmt.Sprintf(", %d security", security))
+		}
+		result.WriteString(":\n\n")
+
+		for i, p := range packages {
+			if i == maxUpdatesListed {
+				result.WriteString(fmt.Sprintf("…and %d more\n", len(packages)-i))
+				break
+			}
+			icon := "•"
+			if p.Security() {
+				icon = "🔒"
+			}
+			result.WriteString(fmt.Sprintf("%s %s %s → %s\n", icon, p.Name, p.OldVersion, p.Version))
+		}
+	}
+
+	if required, pkgs := updates.RebootRequired(); required {
+		result.WriteString("\n🔁 A reboot is required")
+		if len(pkgs) > 0 {
+			result.WriteString(" (" + strings.Join(pkgs, ", ") + ")")
+		}
+		result.WriteString(".\n")
+	}
+
+	return strings.TrimRight(result.String(), "\n")
+}
+
+// handleUpgrade handles /upgrade: it lists what would be installed and
+// asks for confirmation.
+func (h *Handler) handleUpgrade(bot *tgbotapi.BotAPI, message *tgbotapi.Message) error {
+	if !h.isAdmin(message.From.ID) {
+		return errAdminOnly
+	}
+	if h.updates.Upgrading() {
+		return apt.ErrUpgradeRunning
+	}
+
+	packages, err := h.updates.Upgradable(context.Background())
+	if err != nil {
+		return err
+	}
+	text := formatUpdates(h.updates, packages)
+	if len(packages) == 0 {
+		return h.reply(bot, message.Chat.ID, text)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text+"\n\nRefresh the package lists and install these upgrades?")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Upgrade", "upgrade:run"),
+			tgbotapi.NewInlineKeyboardButtonData("✖️ Cancel", "upgrade:cancel"),
+		),
+	)
+	_, err = bot.Send(msg)
+	return err
+}
+
+// handleUpgradeCallback starts a confirmed upgrade in the background. The
+// payload is "run" or "cancel".
+func (h *Handler) handleUpgradeCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery, payload string) error {
+	if query.Message == nil {
+		return nil
+	}
+	if !h.isAdmin(query.From.ID) {
+		return errAdminOnly
+	}
+
+	chatID, messageID := query.Message.Chat.ID, query.Message.MessageID
+	switch payload {
+	case "cancel":
+		_, err := bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, query.Message.Text+"\n\nCancelled."))
+		return err
+	case "run":
+	default:
+		return fmt.Errorf("unknown upgrade action")
+	}
+
+	if h.updates.Upgrading() {
+		return apt.ErrUpgradeRunning
+	}
+	if _, err := bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⏳ Starting upgrade...")); err != nil {
+		return err
+	}
+	h.background(func() {
+		h.runUpgrade(bot, chatID, messageID, query.From.ID)
+	})
+	return nil
+}
+
+// runUpgrade runs the upgrade, editing the progress message with apt-get's
+// latest output until it finishes.
+func (h *Handler) runUpgrade(bot *tgbotapi.BotAPI, chatID int64, messageID int, userID int64) {
+	start := time.Now()
+
+	var mu sync.Mutex
+	var lines []string
+	var summary string
+	changed := false
+	progress := func(line string) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return
+		}
+		if r := []rune(line); len(r) > 120 {
+			line = string(r[:120]) + "…"
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		// e.g. "12 upgraded, 0 newly installed, 0 to remove and 2 not upgraded."
+		if strings.Contains(line, " upgraded, ") {
+			summary = line
+		}
+		lines = append(lines, line)
+		if len(lines) > upgradeProgressLines {
+			lines = lines[len(lines)-upgradeProgressLines:]
+		}
+		changed = true
+	}
+	tail := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		changed = false
+		return strings.Join(lines, "\n")
+	}
+	edit := func(text string) {
+		if _, err := bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, text)); err != nil {
+			log.Printf("Error updating upgrade progress: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(upgradeEditInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				pending := changed
+				mu.Unlock()
+				if pending {
+					elapsed := time.Since(start).Round(time.Second)
+					edit(fmt.Sprintf("⏳ Upgrading for %s...\n\n%s", elapsed, tail()))
+				}
+			}
+		}
+	}()
+
+	// Not tied to the bot's shutdown: interrupting dpkg can leave packages
+	// half-configured
+	err := h.updates.Upgrade(context.Background(), progress)
+	// Wait for a progress edit in flight so it cannot land after the result
+	close(done)
+	<-stopped
+
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	if auditErr := h.db.AddAuditEntry(&storage.AuditEntry{
+		UserID: userID,
+		Action: "upgrade",
+		Target: "apt packages",
+		Result: result,
+	}); auditErr != nil {
+		log.Printf("Error recording audit entry: %v", auditErr)
+	}
+
+	elapsed := time.Since(start).Round(time.Second)
+	var text string
+	if err != nil {
+		text = fmt.Sprintf("❌ Upgrade failed after %s: %v\n\n%s", elapsed, err, tail())
+	} else {
+		text = fmt.Sprintf("✅ Upgrade finished in %s.", elapsed)
+		mu.Lock()
+		if summary != "" {
+			text += "\n" + summary
+		}
+		mu.Unlock()
+		if required, pkgs := h.updates.RebootRequired(); required {
+			text += "\n\n🔁 A reboot is required"
+			if len(pkgs) > 0 {
+				text += " (" + strings.Join(pkgs, ", ") + ")"
+			}
+			text += ". Use /reboot when convenient."
+		} else {
+			text += "\n\nNo reboot is required."
+		}
+	}
+	edit(text)
+}
@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/monitor"
+	"mypibot-go/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const watchUsage = `Usage:
+/watch - list watched processes
+/watch add <name> <name|cmdline|pidfile> <pattern>
+/watch delete <id>`
+
+// loadWatches returns the stored watched processes. Definitions that no
+// longer validate are skipped.
+func loadWatches(db *storage.Database) ([]*monitor.Watch, error) {
+	stored, err := db.ListWatches()
+	if err != nil {
+		return nil, err
+	}
+
+	var watches []*monitor.Watch
+	for _, w := range stored {
+		watch, err := monitor.NewWatch(w.ID, w.Name, w.MatchType, w.Pattern)
+		if err != nil {
+			log.Printf("Skipping watch %d: %v", w.ID, err)
+			continue
+		}
+		watches = append(watches, watch)
+	}
+	return watches, nil
+}
+
+// handleWatch handles /watch, /watch add and /watch delete.
+func (h *Handler) handleWatch(message *tgbotapi.Message) (string, error) {
+	args := strings.Fields(message.CommandArguments())
+	if len(args) == 0 || args[0] == "list" {
+		return h.listWatches(), nil
+	}
+
+	if !h.isAdmin(message.From.ID) {
+		return "", errAdminOnly
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 4 {
+			return "", errors.New(watchUsage)
+		}
+		// Command line patterns may contain spaces
+		pattern := strings.Join(args[3:], " ")
+		watch, err := monitor.NewWatch(0, args[1], args[2], pattern)
+		if err != nil {
+			return "", err
+		}
+
+		id, err := h.db.CreateWatch(&storage.WatchedProcess{
+			Name:      watch.Name,
+			MatchType: watch.Match,
+			Pattern:   watch.Pattern,
+		})
+		h.auditWatch(message.From.ID, "watch add", watch.String(), err)
+		if err != nil {
+			return "", err
+		}
+		watch.ID = id
+		h.watchdog.Add(watch)
+
+		text := fmt.Sprintf("Watching %s. ID: %d", watch, id)
+		if h.watchdog.HasRestart(watch.Name) {
+			text += "\nIts restart command runs if it stops."
+		}
+		return text, nil
+
+	case "delete":
+		if len(args) != 2 {
+			return "", errors.New(watchUsage)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid watch ID %q", args[1])
+		}
+		err = h.db.DeleteWatch(id)
+		h.auditWatch(message.From.ID, "watch delete", fmt.Sprintf("watch %d", id), err)
+		if err != nil {
+			return "", err
+		}
+		h.watchdog.Remove(id)
+		return fmt.Sprintf("Watch %d deleted.", id), nil
+	}
+
+	return "", errors.New(watchUsage)
+}
+
+// auditWatch records a watch change in the audit log.
+func (h *Handler) auditWatch(userID int64, action, target string, err error) {
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	if auditErr := h.db.AddAuditEntry(&storage.AuditEntry{
+		UserID: userID,
+		Action: action,
+		Target: target,
+		Result: result,
+	}); auditErr != nil {
+		log.Printf("Error recording audit entry: %v", auditErr)
+	}
+}
+
+func (h *Handler) listWatches() string {
+	statuses := h.watchdog.Statuses()
+	if len(statuses) == 0 {
+		return "No watched processes. Add one with /watch add."
+	}
+
+	now := time.Now()
+	var result strings.Builder
+	result.WriteString("Watched Processes:\n")
+	for _, s := range statuses {
+		icon := "⚪"
+		state := "not checked yet"
+		switch {
+		case s.Checked && s.Running:
+			icon = "🟢"
+			state = fmt.Sprintf("running, PID %d, up %s", s.PID, formatAge(now.Sub(s.Since)))
+			if s.Count > 1 {
+				state += fmt.Sprintf(", %d processes", s.Count)
+			}
+		case s.Checked:
+			icon = "🔴"
+			state = fmt.Sprintf("not running for %s", formatAge(now.Sub(s.Since)))
+		}
+
+		result.WriteString(fmt.Sprintf("\n%s #%d %s\n  %s\n", icon, s.Watch.ID, s.Watch, state))
+		if h.watchdog.HasRestart(s.Watch.Name) {
+			result.WriteString("  Restart command configured\n")
+		}
+	}
+	return result.String()
+}
+
+// formatAge renders a duration to the minute, e.g. "3d 4h", "2h 5m" or "7m".
+func formatAge(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
@@ -0,0 +1,74 @@
+// Package command runs external programs behind an interface, so code
+// that shells out can be exercised with canned output.
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Runner runs a program and returns its combined stdout and stderr.
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// Streamer runs a program and passes each line of its combined stdout and
+// stderr to fn as it is written, for long jobs that report progress.
+type Streamer interface {
+	Stream(ctx context.Context, fn func(line string), name string, args ...string) error
+}
+
+// Exec runs programs with os/exec.
+type Exec struct{}
+
+func (Exec) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+func (Exec) Stream(ctx context.Context, fn func(line string), name string, args ...string) error {
+	pr, pw := io.Pipe()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		done <- err
+	}()
+
+	scanner := bufio.NewScanner(pr)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	// Keep draining if a line was too long, so the program is not blocked
+	io.Copy(io.Discard, pr)
+	return <-done
+}
+
+// Sudo runs a program through "sudo -n", which fails instead of prompting
+// when no NOPASSWD rule allows it. Errors include the program's output.
+func Sudo(ctx context.Context, r Runner, name string, args ...string) ([]byte, error) {
+	out, err := r.Run(ctx, "sudo", append([]string{"-n", name}, args...)...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return out, fmt.Errorf("%w: %s", err, msg)
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+// SudoStream is Sudo for a Streamer.
+func SudoStream(ctx context.Context, s Streamer, fn func(line string), name string, args ...string) error {
+	return s.Stream(ctx, fn, "sudo", append([]string{"-n", name}, args...)...)
+}
@@ -3,16 +3,111 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const defaultAlertRules = "cpu_percent>90:5m,temperature>75,disk_percent>90,ram_percent>85"
+
 type Config struct {
 	BotToken     string
 	AllowedUsers []int64
+	AdminUsers   []int64
 	DatabasePath string
+	BackupDir    string
+	// BackupInterval is how often the bot writes a backup to BackupDir; 0
+	// when BACKUP_INTERVAL is "off". BackupKeep backups are kept.
+	BackupInterval time.Duration
+	BackupKeep     int
+
+	// HostRoot is the directory /proc and /sys are read from (default "/")
+	HostRoot string
+
+	// StorageMode is either "default" or "sdcard". See storage.Options.
+	StorageMode   string
+	FlushInterval time.Duration
+
+	// IntegrityCheck is "quick", "full" or "off"; see storage.Options.
+	IntegrityCheck string
+
+	Retention []Retention
+
+	// AlertRules are specs like "cpu_percent>90:5m"; see alert.ParseRule.
+	AlertRules      []string
+	AlertChats      []int64
+	AlertInterval   time.Duration
+	AlertCooldown   time.Duration
+	AlertHysteresis float64
+
+	// Probes are specs like "router|ping|192.168.1.1|1m"; see probe.ParseProbe.
+	Probes       []string
+	ProbeTimeout time.Duration
+
+	// WatchRestart maps watched process names to the command that restarts
+	// them. Commands only come from here, never from chat.
+	WatchRestart map[string]string
+
+	// ServiceUnits are the systemd units /service may inspect and control.
+	ServiceUnits []string
+
+	// LogSources are the units, files and "kernel" /logs may read.
+	LogSources []string
+	// LogAlerts are "source|regex" rules; see logs.ParseRule.
+	LogAlerts        []string
+	LogAlertInterval time.Duration
+
+	// DockerSocket is the Docker Engine API socket (default
+	// /var/run/docker.sock).
+	DockerSocket string
+
+	// GPIOLines are the "name=[chip:]offset:in|out[:option...]" lines /gpio
+	// may use; see gpio.ParseLine.
+	GPIOLines []string
+	// GPIOBackend is "chardev" for the kernel's GPIO character devices or
+	// "sim" for a simulated chip.
+	GPIOBackend string
+
+	// SensorListen is the address the sensor endpoint listens on, e.g.
+	// ":8090". Empty disables it.
+	SensorListen string
+	// SensorToken is the bearer token sensors must send.
+	SensorToken string
+	// SensorStaleAfter is how long a sensor may stay silent before its
+	// metrics are dropped and the alert chats are told.
+	SensorStaleAfter time.Duration
+
+	// UpdateDigest is when the weekly digest of pending package updates is
+	// sent to the admins; nil when UPDATE_DIGEST is "off".
+	UpdateDigest *Weekly
+}
+
+// Retention is one RETENTION_POLICIES entry, e.g. "reminder_history:90d"
+// (keep 90 days) or "reminder_history:5000rows" (keep the newest 5000 rows).
+type Retention struct {
+	Table   string
+	MaxAge  time.Duration
+	MaxRows int
+}
+
+// Weekly is a time in the week, e.g. "Mon 09:00", in local time.
+type Weekly struct {
+	Day    time.Weekday
+	Hour   int
+	Minute int
+}
+
+// Next returns the first occurrence of w after t.
+func (w *Weekly) Next(t time.Time) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), w.Hour, w.Minute, 0, 0, t.Location())
+	next = next.AddDate(0, 0, (int(w.Day)-int(t.Weekday())+7)%7)
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
 }
 
 func Load() (*Config, error) {
@@ -31,13 +126,18 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("ALLOWED_USER_IDS is required")
 	}
 
-	var allowedUsers []int64
-	for _, id := range strings.Split(allowedUserIDs, ",") {
-		userID, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+	allowedUsers, err := parseIDs(allowedUserIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admins default to every allowed user, which suits a personal bot
+	adminUsers := allowedUsers
+	if adminUserIDs := os.Getenv("ADMIN_USER_IDS"); adminUserIDs != "" {
+		adminUsers, err = parseIDs(adminUserIDs)
 		if err != nil {
-			return nil, fmt.Errorf("invalid user ID %s: %w", id, err)
+			return nil, err
 		}
-		allowedUsers = append(allowedUsers, userID)
 	}
 
 	databasePath := os.Getenv("DATABASE_PATH")
@@ -45,9 +145,309 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("DATABASE_PATH is required")
 	}
 
+	backupDir := getEnv("BACKUP_DIR", filepath.Join(filepath.Dir(databasePath), "backups"))
+
+	// Backups are what recovery falls back to, so they are on by default
+	var backupInterval time.Duration
+	if value := strings.TrimSpace(os.Getenv("BACKUP_INTERVAL")); value != "off" {
+		backupInterval, err = getEnvDuration("BACKUP_INTERVAL", 24*time.Hour)
+		if err != nil {
+			return nil, err
+		}
+	}
+	backupKeep := 7
+	if value := strings.TrimSpace(os.Getenv("BACKUP_KEEP")); value != "" {
+		backupKeep, err = strconv.Atoi(value)
+		if err != nil || backupKeep <= 0 {
+			return nil, fmt.Errorf("invalid BACKUP_KEEP %q: must be a positive number", value)
+		}
+	}
+
+	storageMode := getEnv("STORAGE_MODE", "default")
+	if storageMode != "default" && storageMode != "sdcard" {
+		return nil, fmt.Errorf("invalid STORAGE_MODE %q: must be \"default\" or \"sdcard\"", storageMode)
+	}
+
+	flushInterval, err := getEnvDuration("STORAGE_FLUSH_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	integrityCheck := getEnv("INTEGRITY_CHECK", "quick")
+	if integrityCheck != "quick" && integrityCheck != "full" && integrityCheck != "off" {
+		return nil, fmt.Errorf("invalid INTEGRITY_CHECK %q: must be \"quick\", \"full\" or \"off\"", integrityCheck)
+	}
+
+	retention, err := parseRetention(getEnv("RETENTION_POLICIES", "reminder_history:90d,probe_results:30d,metric_samples:90d,audit_log:365d"))
+	if err != nil {
+		return nil, err
+	}
+
+	var alertRules []string
+	for _, rule := range strings.Split(getEnv("ALERT_RULES", defaultAlertRules), ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			alertRules = append(alertRules, rule)
+		}
+	}
+
+	// Alerts go to the admins' private chats unless configured otherwise
+	alertChats := adminUsers
+	if alertChatIDs := os.Getenv("ALERT_CHAT_IDS"); alertChatIDs != "" {
+		alertChats, err = parseIDs(alertChatIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	alertInterval, err := getEnvDuration("ALERT_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	alertCooldown, err := getEnvDuration("ALERT_COOLDOWN", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	alertHysteresis, err := getEnvFloat("ALERT_HYSTERESIS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	// Probe specs are ";"-separated because URLs may contain commas
+	var probes []string
+	for _, spec := range strings.Split(os.Getenv("PROBES"), ";") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			probes = append(probes, spec)
+		}
+	}
+	probeTimeout, err := getEnvDuration("PROBE_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	watchRestart, err := parseNamedCommands(os.Getenv("WATCH_RESTART_COMMANDS"))
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceUnits []string
+	for _, unit := range strings.Split(os.Getenv("SERVICE_UNITS"), ",") {
+		if unit = strings.TrimSpace(unit); unit != "" {
+			serviceUnits = append(serviceUnits, unit)
+		}
+	}
+
+	var logSources []string
+	for _, source := range strings.Split(os.Getenv("LOG_SOURCES"), ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			logSources = append(logSources, source)
+		}
+	}
+	// Regexes may contain commas, so rules are ";"-separated
+	var logAlerts []string
+	for _, rule := range strings.Split(os.Getenv("LOG_ALERTS"), ";") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			logAlerts = append(logAlerts, rule)
+		}
+	}
+	logAlertInterval, err := getEnvDuration("LOG_ALERT_INTERVAL", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	var gpioLines []string
+	for _, line := range strings.Split(os.Getenv("GPIO_LINES"), ",") {
+		if line = strings.TrimSpace(line); line != "" {
+			gpioLines = append(gpioLines, line)
+		}
+	}
+	gpioBackend := getEnv("GPIO_BACKEND", "chardev")
+	if gpioBackend != "chardev" && gpioBackend != "sim" {
+		return nil, fmt.Errorf("invalid GPIO_BACKEND %q: must be \"chardev\" or \"sim\"", gpioBackend)
+	}
+
+	sensorListen := getEnv("SENSOR_LISTEN", "")
+	sensorToken := getEnv("SENSOR_TOKEN", "")
+	if sensorListen != "" && len(sensorToken) < 16 {
+		return nil, fmt.Errorf("SENSOR_TOKEN must be at least 16 characters when SENSOR_LISTEN is set")
+	}
+	sensorStaleAfter, err := getEnvDuration("SENSOR_STALE_AFTER", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	updateDigest, err := parseWeekly(getEnv("UPDATE_DIGEST", "Mon 09:00"))
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		BotToken:     botToken,
-		AllowedUsers: allowedUsers,
-		DatabasePath: databasePath,
+		BotToken:       botToken,
+		AllowedUsers:   allowedUsers,
+		AdminUsers:     adminUsers,
+		DatabasePath:   databasePath,
+		BackupDir:      backupDir,
+		BackupInterval: backupInterval,
+		BackupKeep:     backupKeep,
+		HostRoot:       getEnv("HOST_ROOT", "/"),
+		StorageMode:    storageMode,
+		FlushInterval:  flushInterval,
+		IntegrityCheck: integrityCheck,
+		Retention:      retention,
+
+		AlertRules:      alertRules,
+		AlertChats:      alertChats,
+		AlertInterval:   alertInterval,
+		AlertCooldown:   alertCooldown,
+		AlertHysteresis: alertHysteresis,
+
+		Probes:       probes,
+		ProbeTimeout: probeTimeout,
+
+		WatchRestart: watchRestart,
+		ServiceUnits: serviceUnits,
+
+		LogSources:       logSources,
+		LogAlerts:        logAlerts,
+		LogAlertInterval: logAlertInterval,
+
+		DockerSocket: getEnv("DOCKER_SOCKET", "/var/run/docker.sock"),
+
+		GPIOLines:   gpioLines,
+		GPIOBackend: gpioBackend,
+
+		SensorListen:     sensorListen,
+		SensorToken:      sensorToken,
+		SensorStaleAfter: sensorStaleAfter,
+
+		UpdateDigest: updateDigest,
 	}, nil
 }
+
+// parseIDs parses a comma-separated list of Telegram user or chat IDs.
+func parseIDs(list string) ([]int64, error) {
+	var ids []int64
+	for _, id := range strings.Split(list, ",") {
+		if strings.TrimSpace(id) == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user ID %s: %w", id, err)
+		}
+		ids = append(ids, parsed)
+	}
+	return ids, nil
+}
+
+// parseNamedCommands parses a ";"-separated list of "name=command" entries.
+func parseNamedCommands(list string) (map[string]string, error) {
+	commands := make(map[string]string)
+	for _, entry := range strings.Split(list, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, command, ok := strings.Cut(entry, "=")
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if !ok || name == "" || command == "" {
+			return nil, fmt.Errorf("invalid command %q: expected name=command", entry)
+		}
+		commands[name] = command
+	}
+	return commands, nil
+}
+
+// parseRetention parses a comma-separated list of "table:limit" entries,
+// where limit is a number of days ("30d") or rows ("1000rows").
+func parseRetention(list string) ([]Retention, error) {
+	var policies []Retention
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		table, limit, ok := strings.Cut(entry, ":")
+		if !ok || table == "" {
+			return nil, fmt.Errorf("invalid retention policy %q: expected table:limit", entry)
+		}
+
+		policy := Retention{Table: strings.TrimSpace(table)}
+		limit = strings.TrimSpace(limit)
+		switch {
+		case strings.HasSuffix(limit, "rows"):
+			n, err := strconv.Atoi(strings.TrimSuffix(limit, "rows"))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid retention policy %q: bad row count", entry)
+			}
+			policy.MaxRows = n
+		case strings.HasSuffix(limit, "d"):
+			n, err := strconv.Atoi(strings.TrimSuffix(limit, "d"))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid retention policy %q: bad number of days", entry)
+			}
+			policy.MaxAge = time.Duration(n) * 24 * time.Hour
+		default:
+			return nil, fmt.Errorf("invalid retention policy %q: limit must end in \"d\" or \"rows\"", entry)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// parseWeekly parses a weekday and time such as "Mon 09:00", or "off".
+func parseWeekly(value string) (*Weekly, error) {
+	if value == "off" {
+		return nil, nil
+	}
+
+	day, clock, _ := strings.Cut(value, " ")
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return nil, fmt.Errorf("invalid weekly time %q: expected e.g. \"Mon 09:00\" or \"off\"", value)
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(day, d.String()[:3]) || strings.EqualFold(day, d.String()) {
+			return &Weekly{Day: d, Hour: t.Hour(), Minute: t.Minute()}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid weekly time %q: unknown day %q", value, day)
+}
+
+// getEnv returns the value of key, or def when it is unset or empty.
+func getEnv(key, def string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvDuration parses key as a Go duration (e.g. "90s", "5m").
+func getEnvDuration(key string, def time.Duration) (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be positive", key, value)
+	}
+	return d, nil
+}
+
+// getEnvFloat parses key as a non-negative number.
+func getEnvFloat(key string, def float64) (float64, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative number", key, value)
+	}
+	return f, nil
+}
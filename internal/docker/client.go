@@ -0,0 +1,307 @@
+// Package docker is a small client for the Docker Engine API over its unix
+// socket, covering what the bot reports on and controls.
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSocket is where the Docker daemon listens.
+const DefaultSocket = "/var/run/docker.sock"
+
+// apiVersion is the oldest Engine API version with everything used here,
+// so older daemons keep working.
+const apiVersion = "v1.40"
+
+const requestTimeout = 30 * time.Second
+
+// Client talks to the Docker daemon.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a client for the daemon listening on socket.
+func NewClient(socket string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &Client{http: &http.Client{Transport: transport, Timeout: requestTimeout}}
+}
+
+// Container is an entry of the container list.
+type Container struct {
+	ID      string   `json:"Id"`
+	Names   []string `json:"Names"`
+	Image   string   `json:"Image"`
+	State   string   `json:"State"`  // running, exited, restarting, ...
+	Status  string   `json:"Status"` // e.g. "Up 2 hours (healthy)"
+	Created int64    `json:"Created"`
+}
+
+// Name returns the container's primary name without the leading slash.
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		if len(c.ID) > 12 {
+			return c.ID[:12]
+		}
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// Health returns "healthy", "unhealthy" or "starting" from the status, or
+// "" for containers without a health check.
+func (c Container) Health() string {
+	for _, health := range []string{"unhealthy", "healthy", "health: starting"} {
+		if strings.Contains(c.Status, "("+health+")") {
+			return strings.TrimPrefix(health, "health: ")
+		}
+	}
+	return ""
+}
+
+// Stats is a container's resource usage.
+type Stats struct {
+	CPUPercent  float64
+	MemoryUsage uint64
+	MemoryLimit uint64
+	NetRx       uint64
+	NetTx       uint64
+	BlockRead   uint64
+	BlockWrite  uint64
+	PIDs        uint64
+}
+
+// APIError is a non-2xx response from the daemon.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docker: %s (status %d)", e.Message, e.Status)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	u := "http://docker/" + apiVersion + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to docker: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body)
+		if body.Message == "" {
+			body.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, &APIError{Status: resp.StatusCode, Message: body.Message}
+	}
+	return resp, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding docker response: %w", err)
+	}
+	return nil
+}
+
+// Containers lists containers, including stopped ones when all is true.
+func (c *Client) Containers(ctx context.Context, all bool) ([]Container, error) {
+	var containers []Container
+	err := c.getJSON(ctx, "/containers/json", url.Values{"all": {strconv.FormatBool(all)}}, &containers)
+	return containers, err
+}
+
+// Find returns the container with the given name or ID prefix.
+func (c *Client) Find(ctx context.Context, name string) (*Container, error) {
+	containers, err := c.Containers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		if container.Name() == name {
+			return &container, nil
+		}
+	}
+	var match *Container
+	for i, container := range containers {
+		if len(name) >= 4 && strings.HasPrefix(container.ID, name) {
+			if match != nil {
+				return nil, fmt.Errorf("%q matches more than one container", name)
+			}
+			match = &containers[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no container named %q", name)
+	}
+	return match, nil
+}
+
+// statsResponse is the part of /containers/{id}/stats used here.
+type statsResponse struct {
+	CPUStats    cpuStats `json:"cpu_stats"`
+	PreCPUStats cpuStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IOServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PIDsStats struct {
+		Current uint64 `json:"current"`
+	} `json:"pids_stats"`
+}
+
+type cpuStats struct {
+	CPUUsage struct {
+		TotalUsage uint64 `json:"total_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint64 `json:"online_cpus"`
+}
+
+// Stats reads a one-off resource usage sample. CPU usage covers the
+// daemon's last sampling interval.
+func (c *Client) Stats(ctx context.Context, id string) (*Stats, error) {
+	var raw statsResponse
+	err := c.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/stats", url.Values{"stream": {"false"}}, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		MemoryUsage: raw.MemoryStats.Usage,
+		MemoryLimit: raw.MemoryStats.Limit,
+		PIDs:        raw.PIDsStats.Current,
+	}
+	// Like docker stats, leave out the page cache (cgroup v1 "cache",
+	// v2 "inactive_file")
+	for _, key := range []string{"inactive_file", "cache"} {
+		if cache, ok := raw.MemoryStats.Stats[key]; ok && cache < stats.MemoryUsage {
+			stats.MemoryUsage -= cache
+			break
+		}
+	}
+
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) - float64(raw.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpus := float64(raw.CPUStats.OnlineCPUs)
+		if cpus == 0 {
+			cpus = 1
+		}
+		stats.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+
+	for _, n := range raw.Networks {
+		stats.NetRx += n.RxBytes
+		stats.NetTx += n.TxBytes
+	}
+	for _, entry := range raw.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWrite += entry.Value
+		}
+	}
+	return stats, nil
+}
+
+// Logs returns the last n lines of a container's stdout and stderr.
+func (c *Client) Logs(ctx context.Context, id string, n int) (string, error) {
+	query := url.Values{
+		"stdout":     {"true"},
+		"stderr":     {"true"},
+		"tail":       {strconv.Itoa(n)},
+		"timestamps": {"false"},
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/logs", query)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", fmt.Errorf("error reading container logs: %w", err)
+	}
+	// Containers without a TTY multiplex stdout and stderr in frames
+	if resp.Header.Get("Content-Type") != "application/vnd.docker.raw-stream" {
+		data = demultiplex(data)
+	}
+	return strings.ToValidUTF8(string(data), "�"), nil
+}
+
+// demultiplex strips the 8-byte frame headers (stream, 3 zero bytes,
+// big-endian length) from a multiplexed log stream. Data that does not look
+// framed is returned unchanged.
+func demultiplex(data []byte) []byte {
+	var out []byte
+	rest := data
+	for len(rest) >= 8 {
+		if rest[0] > 2 || rest[1] != 0 || rest[2] != 0 || rest[3] != 0 {
+			return data
+		}
+		size := int(binary.BigEndian.Uint32(rest[4:8]))
+		if len(rest) < 8+size {
+			return data
+		}
+		out = append(out, rest[8:8+size]...)
+		rest = rest[8+size:]
+	}
+	if len(rest) != 0 {
+		return data
+	}
+	return out
+}
+
+// Restart restarts a container, giving it 10 seconds to stop.
+func (c *Client) Restart(ctx context.Context, id string) error {
+	resp, err := c.do(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/restart", url.Values{"t": {"10"}})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
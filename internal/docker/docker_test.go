@@ -0,0 +1,375 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDaemon serves a small part of the Engine API on a unix socket.
+type fakeDaemon struct {
+	mu         sync.Mutex
+	containers []Container
+	restarted  []string
+	logs       []byte
+	logsType   string
+}
+
+func (d *fakeDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+apiVersion)
+	switch {
+	case r.Method == http.MethodGet && path == "/containers/json":
+		json.NewEncoder(w).Encode(d.containers)
+	case r.Method == http.MethodGet && path == "/containers/abc123/stats":
+		w.Write([]byte(`{
+			"cpu_stats": {"cpu_usage": {"total_usage": 300}, "system_cpu_usage": 2000, "online_cpus": 4},
+			"precpu_stats": {"cpu_usage": {"total_usage": 100}, "system_cpu_usage": 1000},
+			"memory_stats": {"usage": 5000, "limit": 100000, "stats": {"inactive_file": 1000}},
+			"networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+			"blkio_stats": {"io_service_bytes_recursive": [
+				{"op": "Read", "value": 7}, {"op": "Write", "value": 9}, {"op": "read", "value": 3}]},
+			"pids_stats": {"current": 12}
+		}`))
+	case r.Method == http.MethodGet && path == "/containers/abc123/logs":
+		if r.URL.Query().Get("tail") != "50" {
+			http.Error(w, `{"message": "unexpected tail"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", d.logsType)
+		w.Write(d.logs)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/restart"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/restart")
+		if id != "abc123" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "No such container: ` + id + `"}`))
+			return
+		}
+		d.restarted = append(d.restarted, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (d *fakeDaemon) set(containers ...Container) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.containers = containers
+}
+
+// serve starts daemon on a socket in a temporary directory and returns a
+// client for it.
+func serve(t *testing.T, daemon http.Handler) (*Client, string) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(daemon)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return NewClient(socket), socket
+}
+
+func TestContainerName(t *testing.T) {
+	tests := []struct {
+		c    Container
+		want string
+	}{
+		{Container{ID: "abc123def4567890", Names: []string{"/web", "/alias"}}, "web"},
+		{Container{ID: "abc123def4567890"}, "abc123def456"},
+		{Container{ID: "abc"}, "abc"},
+		{Container{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Name(); got != tt.want {
+			t.Errorf("%+v.Name() = %q, want %q", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestContainerHealth(t *testing.T) {
+	tests := map[string]string{
+		"Up 2 hours (healthy)":            "healthy",
+		"Up 3 minutes (unhealthy)":        "unhealthy",
+		"Up 5 seconds (health: starting)": "starting",
+		"Up 1 hour (healthy) (Paused)":    "healthy",
+		"Up 2 hours":                      "",
+		"Exited (0) 3 hours ago":          "",
+	}
+	for status, want := range tests {
+		if got := (Container{Status: status}).Health(); got != want {
+			t.Errorf("Health() of %q = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	daemon := &fakeDaemon{}
+	daemon.set(
+		Container{ID: "abc123def456", Names: []string{"/web"}},
+		Container{ID: "abd999000111", Names: []string{"/db"}},
+		Container{ID: "ffff00001111", Names: []string{"/abc1"}},
+	)
+	client, _ := serve(t, daemon)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		wantID  string
+		wantErr string
+	}{
+		{name: "web", wantID: "abc123def456"},
+		{name: "abd9", wantID: "abd999000111"},
+		{name: "abc1", wantID: "ffff00001111"}, // a name wins over an ID prefix
+		{name: "ab", wantErr: "no container"},  // prefixes need 4 characters
+		{name: "missing", wantErr: "no container"},
+	}
+	for _, tt := range tests {
+		c, err := client.Find(ctx, tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Find(%q) error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || c.ID != tt.wantID {
+			t.Errorf("Find(%q) = %+v, %v; want %s", tt.name, c, err, tt.wantID)
+		}
+	}
+
+	daemon.set(
+		Container{ID: "abc123def456", Names: []string{"/web"}},
+		Container{ID: "abc123999999", Names: []string{"/web2"}},
+	)
+	if _, err := client.Find(ctx, "abc123"); err == nil || !strings.Contains(err.Error(), "more than one") {
+		t.Errorf("Find() of an ambiguous prefix error = %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	client, _ := serve(t, &fakeDaemon{})
+
+	stats, err := client.Stats(context.Background(), "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Stats{
+		CPUPercent:  80, // 200 of 1000 system ticks over 4 CPUs
+		MemoryUsage: 4000,
+		MemoryLimit: 100000,
+		NetRx:       11,
+		NetTx:       22,
+		BlockRead:   10,
+		BlockWrite:  9,
+		PIDs:        12,
+	}
+	if math.Abs(stats.CPUPercent-want.CPUPercent) > 1e-9 {
+		t.Errorf("CPUPercent = %v, want %v", stats.CPUPercent, want.CPUPercent)
+	}
+	stats.CPUPercent = want.CPUPercent
+	if *stats != want {
+		t.Errorf("Stats() = %+v, want %+v", *stats, want)
+	}
+}
+
+// frame builds one multiplexed log frame.
+func frame(stream byte, text string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(text)))
+	return append(header, text...)
+}
+
+func TestDemultiplex(t *testing.T) {
+	framed := bytes.Join([][]byte{frame(1, "out 1\n"), frame(2, "err 1\n"), frame(1, "out 2\n")}, nil)
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{name: "framed", in: framed, want: "out 1\nerr 1\nout 2\n"},
+		{name: "empty frame", in: frame(1, ""), want: ""},
+		{name: "empty", in: nil, want: ""},
+		{name: "plain text", in: []byte("plain log line\n"), want: "plain log line\n"},
+		{name: "truncated frame", in: framed[:len(framed)-2], want: string(framed[:len(framed)-2])},
+		{name: "trailing bytes", in: append(append([]byte{}, framed...), 1, 0), want: string(framed) + "\x01\x00"},
+		{name: "bad stream", in: frame(5, "x"), want: string(frame(5, "x"))},
+	}
+	for _, tt := range tests {
+		if got := string(demultiplex(tt.in)); got != tt.want {
+			t.Errorf("%s: demultiplex() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogs(t *testing.T) {
+	daemon := &fakeDaemon{}
+	client, _ := serve(t, daemon)
+	ctx := context.Background()
+
+	daemon.logs = append(frame(1, "started\n"), frame(2, "warning\n")...)
+	daemon.logsType = "application/vnd.docker.multiplexed-stream"
+	text, err := client.Logs(ctx, "abc123", 50)
+	if err != nil || text != "started\nwarning\n" {
+		t.Errorf("Logs() of a multiplexed stream = %q, %v", text, err)
+	}
+
+	// TTY containers send the raw stream, which must be left alone even
+	// if it happens to look framed
+	daemon.logs = frame(1, "tty\n")
+	daemon.logsType = "application/vnd.docker.raw-stream"
+	text, err = client.Logs(ctx, "abc123", 50)
+	if err != nil || text != string(frame(1, "tty\n")) {
+		t.Errorf("Logs() of a raw stream = %q, %v", text, err)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	daemon := &fakeDaemon{}
+	client, _ := serve(t, daemon)
+	ctx := context.Background()
+
+	if err := client.Restart(ctx, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	if len(daemon.restarted) != 1 {
+		t.Errorf("restarted %v", daemon.restarted)
+	}
+
+	err := client.Restart(ctx, "gone")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound || apiErr.Message != "No such container: gone" {
+		t.Errorf("Restart() of a missing container error = %#v", err)
+	}
+}
+
+func TestWatcherEvents(t *testing.T) {
+	daemon := &fakeDaemon{}
+	client, _ := serve(t, daemon)
+	w := NewWatcher(client)
+
+	web := Container{ID: "web1", Names: []string{"/web"}, State: "running", Status: "Up 1 hour (healthy)"}
+	stopped := Container{ID: "old1", Names: []string{"/old"}, State: "exited", Status: "Exited (0) 2 days ago"}
+	sick := Container{ID: "api1", Names: []string{"/api"}, State: "running", Status: "Up 5 minutes (unhealthy)"}
+
+	with := func(c Container, state, status string) Container {
+		c.State, c.Status = state, status
+		return c
+	}
+
+	steps := []struct {
+		name       string
+		containers []Container
+		want       []string
+	}{
+		{
+			// Stopped containers may be stopped on purpose
+			name:       "first poll",
+			containers: []Container{web, stopped, sick},
+			want:       []string{"🐳 Container api is unhealthy"},
+		},
+		{name: "no change", containers: []Container{web, stopped, sick}},
+		{
+			name:       "web exits, api recovers",
+			containers: []Container{with(web, "exited", "Exited (1) 1 second ago"), stopped, with(sick, "running", "Up 6 minutes (healthy)")},
+			want:       []string{"🐳 Container web stopped", "✅ Container api is healthy again"},
+		},
+		{
+			name:       "web is back",
+			containers: []Container{web, stopped, with(sick, "running", "Up 6 minutes (healthy)")},
+			want:       []string{"✅ Container web is running again"},
+		},
+		{
+			name:       "web restarting, new container, api removed",
+			containers: []Container{with(web, "restarting", "Restarting (1) 1 second ago"), {ID: "new1", Names: []string{"/new"}, State: "running"}},
+			want:       []string{"🐳 Container web is restarting", "🐳 Container api was removed while running"},
+		},
+		{
+			// Recreated under the same name, as docker compose up does
+			name:       "new recreated, web removed while restarting",
+			containers: []Container{{ID: "new2", Names: []string{"/new"}, State: "running"}},
+		},
+	}
+
+	for _, step := range steps {
+		daemon.set(step.containers...)
+		events, err := w.Events()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if len(events) != len(step.want) {
+			t.Errorf("%s: events %q, want %q", step.name, events, step.want)
+			continue
+		}
+		for i := range events {
+			if !strings.HasPrefix(events[i], step.want[i]) {
+				t.Errorf("%s: event %q, want %q", step.name, events[i], step.want[i])
+			}
+		}
+	}
+}
+
+func TestWatcherRetriesUnreachableDaemon(t *testing.T) {
+	// Docker starts after the bot: nothing listens on the socket yet
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	w := NewWatcher(NewClient(socket))
+
+	for i := 0; i < 2; i++ {
+		if events, err := w.Events(); err != nil || len(events) != 0 {
+			t.Fatalf("Events() without a daemon = %q, %v", events, err)
+		}
+	}
+	if w.retryDelay != minRetryDelay {
+		t.Errorf("retry delay %v after one failed poll, want %v", w.retryDelay, minRetryDelay)
+	}
+
+	// Back off further with every failure, up to the maximum
+	for i := 0; i < 10; i++ {
+		w.retryAt = time.Time{}
+		w.Events()
+	}
+	if w.retryDelay != maxRetryDelay {
+		t.Errorf("retry delay %v, want %v", w.retryDelay, maxRetryDelay)
+	}
+
+	// Once it is up the watcher picks it up on the next retry
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daemon := &fakeDaemon{}
+	daemon.set(Container{ID: "api1", Names: []string{"/api"}, State: "running", Status: "Up 1 second (unhealthy)"})
+	server := httptest.NewUnstartedServer(daemon)
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	if events, _ := w.Events(); len(events) != 0 {
+		t.Errorf("polled before the retry was due: %q", events)
+	}
+	w.retryAt = time.Now().Add(-time.Second)
+	events, err := w.Events()
+	if err != nil || len(events) != 1 || !strings.Contains(events[0], "api is unhealthy") {
+		t.Errorf("Events() after the daemon came up = %q, %v", events, err)
+	}
+	if w.retryDelay != 0 {
+		t.Errorf("retry delay %v after a successful poll, want 0", w.retryDelay)
+	}
+}
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Retry delays while the daemon cannot be reached, e.g. when Docker starts
+// after the bot or is not installed. The delay doubles up to the maximum.
+const (
+	minRetryDelay = time.Minute
+	maxRetryDelay = 30 * time.Minute
+)
+
+type containerState struct {
+	name   string
+	state  string
+	health string
+}
+
+// Watcher reports containers that exit, become unhealthy or are removed
+// while running, and their recovery. It is an alert.EventSource.
+type Watcher struct {
+	mu         sync.Mutex
+	client     *Client
+	started    bool
+	containers map[string]containerState // by ID
+
+	retryDelay time.Duration // zero while the daemon is reachable
+	retryAt    time.Time
+}
+
+func NewWatcher(client *Client) *Watcher {
+	return &Watcher{client: client, containers: make(map[string]containerState)}
+}
+
+// Events compares the containers with the previous call. The first call
+// only reports containers that are already unhealthy; stopped containers
+// may well be stopped on purpose.
+func (w *Watcher) Events() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(w.retryAt) {
+		return nil, nil
+	}
+
+	containers, err := w.client.Containers(context.Background(), true)
+	if err != nil {
+		// Say so once per outage instead of on every poll
+		if w.retryDelay == 0 {
+			log.Printf("Docker is not reachable, retrying in the background: %v", err)
+			w.retryDelay = minRetryDelay
+		} else if w.retryDelay *= 2; w.retryDelay > maxRetryDelay {
+			w.retryDelay = maxRetryDelay
+		}
+		w.retryAt = now.Add(w.retryDelay)
+		return nil, nil
+	}
+	if w.retryDelay != 0 {
+		log.Printf("Docker is reachable again")
+		w.retryDelay, w.retryAt = 0, time.Time{}
+	}
+
+	var events []string
+	current := make(map[string]containerState)
+	names := make(map[string]bool)
+	for _, c := range containers {
+		state := containerState{name: c.Name(), state: c.State, health: c.Health()}
+		current[c.ID] = state
+		names[state.name] = true
+
+		before, seen := w.containers[c.ID]
+		if !w.started || !seen {
+			if state.health == "unhealthy" {
+				events = append(events, fmt.Sprintf("🐳 Container %s is unhealthy: %s", c.Name(), c.Status))
+			}
+			continue
+		}
+
+		switch {
+		case before.state == "running" && (state.state == "exited" || state.state == "dead"):
+			events = append(events, fmt.Sprintf("🐳 Container %s stopped: %s", c.Name(), c.Status))
+		case before.state == "running" && state.state == "restarting":
+			events = append(events, fmt.Sprintf("🐳 Container %s is restarting: %s", c.Name(), c.Status))
+		case before.state != "running" && state.state == "running" && before.state != "created":
+			events = append(events, fmt.Sprintf("✅ Container %s is running again", c.Name()))
+		}
+
+		switch {
+		case state.health == "unhealthy" && before.health != "unhealthy":
+			events = append(events, fmt.Sprintf("🐳 Container %s is unhealthy: %s", c.Name(), c.Status))
+		case state.health == "healthy" && before.health == "unhealthy":
+			events = append(events, fmt.Sprintf("✅ Container %s is healthy again", c.Name()))
+		}
+	}
+
+	// Containers are listed stopped or not, so a running one that is gone
+	// was removed, e.g. with docker rm -f. One recreated under the same
+	// name, as docker compose up does, is not reported.
+	var removed []string
+	for id, before := range w.containers {
+		if _, ok := current[id]; !ok && before.state == "running" && !names[before.name] {
+			removed = append(removed, before.name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		events = append(events, fmt.Sprintf("🐳 Container %s was removed while running", name))
+	}
+
+	w.containers = current
+	w.started = true
+	return events, nil
+}
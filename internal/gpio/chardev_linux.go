@@ -0,0 +1,274 @@
+//go:build linux
+
+package gpio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// GPIO character device uAPI v2, from <linux/gpio.h>.
+const (
+	lineFlagActiveLow          = 1 << 1
+	lineFlagInput              = 1 << 2
+	lineFlagOutput             = 1 << 3
+	lineFlagEdgeRising         = 1 << 4
+	lineFlagEdgeFalling        = 1 << 5
+	lineFlagBiasPullUp         = 1 << 8
+	lineFlagBiasPullDown       = 1 << 9
+	lineFlagBiasDisabled       = 1 << 10
+	lineFlagEventClockRealtime = 1 << 11
+
+	lineAttrOutputValues = 2
+	lineAttrDebounce     = 3
+
+	lineEventRisingEdge = 1
+)
+
+// inputDebounce filters contact bounce on inputs, e.g. a reed switch.
+const inputDebounce = 10 * time.Millisecond
+
+type lineAttribute struct {
+	id      uint32
+	padding uint32
+	value   uint64 // flags, output values or debounce period in µs
+}
+
+type lineConfigAttribute struct {
+	attr lineAttribute
+	mask uint64
+}
+
+type lineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [10]lineConfigAttribute
+}
+
+type lineRequest struct {
+	offsets         [64]uint32
+	consumer        [32]byte
+	config          lineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+type lineValues struct {
+	bits uint64
+	mask uint64
+}
+
+// lineEventSize is sizeof(struct gpio_v2_line_event).
+const lineEventSize = 48
+
+func iowr(nr, size uintptr) uintptr {
+	return 3<<30 | size<<16 | 0xB4<<8 | nr
+}
+
+var (
+	ioctlGetLine       = iowr(0x07, unsafe.Sizeof(lineRequest{}))
+	ioctlLineSetConfig = iowr(0x0D, unsafe.Sizeof(lineConfig{}))
+	ioctlLineGetValues = iowr(0x0E, unsafe.Sizeof(lineValues{}))
+	ioctlLineSetValues = iowr(0x0F, unsafe.Sizeof(lineValues{}))
+)
+
+// consumer is how the bot's lines are labelled in gpioinfo.
+const consumer = "pikuttan"
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+type chardevChip struct {
+	file *os.File
+}
+
+// OpenChip opens a GPIO character device by name, e.g. "gpiochip0", or by
+// path.
+func OpenChip(name string) (Chip, error) {
+	path := name
+	if !strings.ContainsRune(name, '/') {
+		path = filepath.Join("/dev", name)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error opening GPIO chip: %w", err)
+	}
+	return &chardevChip{file: f}, nil
+}
+
+// Request implements Chip. Inputs are claimed as inputs with their bias and
+// debounced; outputs are claimed as they are, so the line keeps its level
+// until the first Set.
+func (c *chardevChip) Request(offset int, line *Line) (Handle, error) {
+	var req lineRequest
+	req.offsets[0] = uint32(offset)
+	req.numLines = 1
+	copy(req.consumer[:], consumer)
+	req.config = lineConfigFor(line, false)
+
+	if err := ioctl(c.file, ioctlGetLine, unsafe.Pointer(&req)); err != nil {
+		if errors.Is(err, syscall.EBUSY) {
+			return nil, fmt.Errorf("line is in use by another program or driver")
+		}
+		return nil, err
+	}
+
+	fd := int(req.fd)
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("gpio-line-%d", offset))
+	return &chardevHandle{file: f, line: line}, nil
+}
+
+// Close implements Chip. Claimed lines stay valid until their own Close.
+func (c *chardevChip) Close() error {
+	return c.file.Close()
+}
+
+// lineConfigFor builds the configuration for a line. Inputs can have edge
+// detection on, and their event timestamps use the realtime clock so they
+// can be shown as wall time.
+func lineConfigFor(line *Line, edges bool) lineConfig {
+	var cfg lineConfig
+	if line.ActiveLow {
+		cfg.flags |= lineFlagActiveLow
+	}
+	if line.Output {
+		return cfg
+	}
+
+	cfg.flags |= lineFlagInput
+	switch line.Bias {
+	case BiasPullUp:
+		cfg.flags |= lineFlagBiasPullUp
+	case BiasPullDown:
+		cfg.flags |= lineFlagBiasPullDown
+	case BiasDisabled:
+		cfg.flags |= lineFlagBiasDisabled
+	}
+	if edges {
+		cfg.flags |= lineFlagEdgeRising | lineFlagEdgeFalling | lineFlagEventClockRealtime
+	}
+
+	cfg.numAttrs = 1
+	cfg.attrs[0] = lineConfigAttribute{
+		attr: lineAttribute{id: lineAttrDebounce, value: uint64(inputDebounce / time.Microsecond)},
+		mask: 1,
+	}
+	return cfg
+}
+
+type chardevHandle struct {
+	file    *os.File
+	line    *Line
+	driving bool // switched to an output by Set
+}
+
+func (h *chardevHandle) Get() (int, error) {
+	values := lineValues{mask: 1}
+	if err := ioctl(h.file, ioctlLineGetValues, unsafe.Pointer(&values)); err != nil {
+		return 0, fmt.Errorf("error reading line: %w", err)
+	}
+	return int(values.bits & 1), nil
+}
+
+func (h *chardevHandle) Set(value int) error {
+	if !h.driving {
+		cfg := lineConfigFor(h.line, false)
+		cfg.flags |= lineFlagOutput
+		cfg.numAttrs = 1
+		cfg.attrs[0] = lineConfigAttribute{
+			attr: lineAttribute{id: lineAttrOutputValues, value: uint64(value)},
+			mask: 1,
+		}
+		if err := ioctl(h.file, ioctlLineSetConfig, unsafe.Pointer(&cfg)); err != nil {
+			return fmt.Errorf("error switching line to output: %w", err)
+		}
+		h.driving = true
+		return nil
+	}
+
+	values := lineValues{bits: uint64(value), mask: 1}
+	if err := ioctl(h.file, ioctlLineSetValues, unsafe.Pointer(&values)); err != nil {
+		return fmt.Errorf("error setting line: %w", err)
+	}
+	return nil
+}
+
+// Watch turns on edge detection for the watch and off again afterwards, so
+// no stale edges are queued in between. Edges timestamped before the watch
+// started are dropped all the same.
+func (h *chardevHandle) Watch(ctx context.Context, fn func(value int, at time.Time)) error {
+	cfg := lineConfigFor(h.line, true)
+	if err := ioctl(h.file, ioctlLineSetConfig, unsafe.Pointer(&cfg)); err != nil {
+		return fmt.Errorf("error enabling edge detection: %w", err)
+	}
+	defer func() {
+		cfg := lineConfigFor(h.line, false)
+		ioctl(h.file, ioctlLineSetConfig, unsafe.Pointer(&cfg))
+	}()
+
+	start := time.Now()
+	if err := h.file.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		h.file.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	buf := make([]byte, lineEventSize*16)
+	for {
+		n, err := h.file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("error reading edge events: %w", err)
+		}
+
+		for event := buf[:n]; len(event) >= lineEventSize; event = event[lineEventSize:] {
+			at := time.Unix(0, int64(binary.NativeEndian.Uint64(event[0:8])))
+			if at.Before(start) {
+				continue
+			}
+			value := 0
+			if binary.NativeEndian.Uint32(event[8:12]) == lineEventRisingEdge {
+				value = 1
+			}
+			fn(value, at)
+		}
+	}
+}
+
+func (h *chardevHandle) Close() error {
+	return h.file.Close()
+}
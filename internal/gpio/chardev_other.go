@@ -0,0 +1,10 @@
+//go:build !linux
+
+package gpio
+
+import "errors"
+
+// OpenChip opens a GPIO character device, which only exists on Linux.
+func OpenChip(name string) (Chip, error) {
+	return nil, errors.New("GPIO character devices are only available on Linux")
+}
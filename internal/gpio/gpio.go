@@ -0,0 +1,305 @@
+// Package gpio reads, drives and watches named GPIO lines. The kernel's GPIO
+// character device is the real backend; a simulated chip stands in for it
+// off the Pi.
+package gpio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChip is the chip lines are on unless their spec names another.
+// On every Pi model the header pins are on gpiochip0.
+const DefaultChip = "gpiochip0"
+
+// Bias selects a line's internal pull resistor.
+type Bias int
+
+const (
+	BiasAsIs Bias = iota
+	BiasPullUp
+	BiasPullDown
+	BiasDisabled
+)
+
+// Line is a named line from the configuration.
+type Line struct {
+	Name      string
+	Chip      string // e.g. "gpiochip0" or a device path
+	Offset    int
+	Output    bool
+	ActiveLow bool
+	Bias      Bias
+}
+
+// Event is an edge seen on a watched line. Value is the logical value after
+// the edge, so 1 on a rising edge of an active-high line.
+type Event struct {
+	Line  string
+	Value int
+	At    time.Time
+}
+
+// Chip is a GPIO controller backend.
+type Chip interface {
+	// Request claims a line. It stays claimed until the Handle is closed.
+	Request(offset int, line *Line) (Handle, error)
+	Close() error
+}
+
+// Handle is a claimed line.
+type Handle interface {
+	// Get reads the line's logical value.
+	Get() (int, error)
+	// Set drives the line, switching it to an output first if needed.
+	Set(value int) error
+	// Watch calls fn for every edge from now until ctx is done. It returns
+	// nil when ctx ends the watch.
+	Watch(ctx context.Context, fn func(value int, at time.Time)) error
+	Close() error
+}
+
+// ErrNotOutput is returned when setting a line configured as an input.
+var ErrNotOutput = errors.New("line is not configured as an output")
+
+// ParseLine parses a line spec of the form
+// "name=[chip:]offset:in|out[:option...]" with the options "active-low",
+// "pull-up", "pull-down" and "bias-disabled", e.g. "relay=17:out" or
+// "door=gpiochip0:27:in:pull-up".
+func ParseLine(spec string) (*Line, error) {
+	name, rest, ok := strings.Cut(strings.TrimSpace(spec), "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return nil, fmt.Errorf("invalid GPIO line %q: expected name=[chip:]offset:in|out", spec)
+	}
+
+	fields := strings.Split(rest, ":")
+	line := &Line{Name: name, Chip: DefaultChip}
+	if _, err := strconv.Atoi(fields[0]); err != nil && len(fields) > 1 {
+		line.Chip = fields[0]
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid GPIO line %q: expected name=[chip:]offset:in|out", spec)
+	}
+
+	offset, err := strconv.Atoi(fields[0])
+	if err != nil || offset < 0 {
+		return nil, fmt.Errorf("invalid GPIO line %q: bad offset %q", spec, fields[0])
+	}
+	line.Offset = offset
+
+	switch fields[1] {
+	case "in":
+	case "out":
+		line.Output = true
+	default:
+		return nil, fmt.Errorf("invalid GPIO line %q: direction must be in or out", spec)
+	}
+
+	for _, option := range fields[2:] {
+		switch option {
+		case "active-low":
+			line.ActiveLow = true
+		case "pull-up":
+			line.Bias = BiasPullUp
+		case "pull-down":
+			line.Bias = BiasPullDown
+		case "bias-disabled":
+			line.Bias = BiasDisabled
+		default:
+			return nil, fmt.Errorf("invalid GPIO line %q: unknown option %q", spec, option)
+		}
+	}
+	return line, nil
+}
+
+// Controller gives access to the configured lines only. Lines are claimed
+// on first use and stay claimed, so outputs hold their value.
+type Controller struct {
+	open  func(chip string) (Chip, error)
+	lines []*Line
+
+	mu      sync.Mutex
+	chips   map[string]Chip
+	handles map[string]Handle
+	watches map[string]context.CancelFunc
+}
+
+// NewController returns a controller for lines, opening chips with open,
+// e.g. OpenChip or a simulated chip.
+func NewController(lines []*Line, open func(chip string) (Chip, error)) *Controller {
+	return &Controller{
+		open:    open,
+		lines:   lines,
+		chips:   make(map[string]Chip),
+		handles: make(map[string]Handle),
+		watches: make(map[string]context.CancelFunc),
+	}
+}
+
+// Lines returns the configured lines.
+func (c *Controller) Lines() []*Line {
+	return c.lines
+}
+
+// Line finds a configured line by name.
+func (c *Controller) Line(name string) (*Line, error) {
+	for _, line := range c.lines {
+		if line.Name == name {
+			return line, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown GPIO line %q", name)
+}
+
+func (c *Controller) handle(name string) (*Line, Handle, error) {
+	line, err := c.Line(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if h, ok := c.handles[name]; ok {
+		return line, h, nil
+	}
+
+	chip, ok := c.chips[line.Chip]
+	if !ok {
+		chip, err = c.open(line.Chip)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.chips[line.Chip] = chip
+	}
+
+	h, err := chip.Request(line.Offset, line)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error requesting %s line %d: %w", line.Chip, line.Offset, err)
+	}
+	c.handles[name] = h
+	return line, h, nil
+}
+
+// Get reads a line's logical value.
+func (c *Controller) Get(name string) (int, error) {
+	_, h, err := c.handle(name)
+	if err != nil {
+		return 0, err
+	}
+	return h.Get()
+}
+
+// Set drives an output line to value, 0 or 1.
+func (c *Controller) Set(name string, value int) error {
+	if value != 0 && value != 1 {
+		return fmt.Errorf("invalid value %d: must be 0 or 1", value)
+	}
+	line, err := c.Line(name)
+	if err != nil {
+		return err
+	}
+	if !line.Output {
+		return ErrNotOutput
+	}
+
+	_, h, err := c.handle(name)
+	if err != nil {
+		return err
+	}
+	return h.Set(value)
+}
+
+// Watch starts calling fn for every edge on an input line. When the watch
+// fails, fn is called once with the error and the watch ends.
+func (c *Controller) Watch(name string, fn func(Event, error)) error {
+	line, h, err := c.handle(name)
+	if err != nil {
+		return err
+	}
+	if line.Output {
+		return fmt.Errorf("%s is an output; only inputs can be watched", name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.watches[name]; ok {
+		return fmt.Errorf("%s is already being watched", name)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.watches[name] = cancel
+
+	go func() {
+		err := h.Watch(ctx, func(value int, at time.Time) {
+			fn(Event{Line: name, Value: value, At: at}, nil)
+		})
+
+		// A cancelled watch was ended by Unwatch or Close
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.mu.Lock()
+		delete(c.watches, name)
+		c.mu.Unlock()
+		cancel()
+
+		if err == nil {
+			err = errors.New("watch ended")
+		}
+		log.Printf("Error watching GPIO line %s: %v", name, err)
+		fn(Event{Line: name}, err)
+	}()
+	return nil
+}
+
+// Unwatch stops watching a line.
+func (c *Controller) Unwatch(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cancel, ok := c.watches[name]
+	if !ok {
+		return fmt.Errorf("%s is not being watched", name)
+	}
+	cancel()
+	delete(c.watches, name)
+	return nil
+}
+
+// Watching reports whether a line is being watched.
+func (c *Controller) Watching(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.watches[name]
+	return ok
+}
+
+// Close ends all watches and releases every line and chip.
+func (c *Controller) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, cancel := range c.watches {
+		cancel()
+		delete(c.watches, name)
+	}
+	var errs []error
+	for name, h := range c.handles {
+		errs = append(errs, h.Close())
+		delete(c.handles, name)
+	}
+	for name, chip := range c.chips {
+		errs = append(errs, chip.Close())
+		delete(c.chips, name)
+	}
+	return errors.Join(errs...)
+}
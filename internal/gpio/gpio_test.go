@@ -0,0 +1,242 @@
+package gpio
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Line
+		wantErr bool
+	}{
+		{spec: "relay=17:out", want: Line{Name: "relay", Chip: DefaultChip, Offset: 17, Output: true}},
+		{
+			spec: "door=gpiochip1:27:in:pull-up:active-low",
+			want: Line{Name: "door", Chip: "gpiochip1", Offset: 27, ActiveLow: true, Bias: BiasPullUp},
+		},
+		{spec: "pir=4:in:pull-down", want: Line{Name: "pir", Chip: DefaultChip, Offset: 4, Bias: BiasPullDown}},
+		{spec: "fan=/dev/gpiochip0:12:out", want: Line{Name: "fan", Chip: "/dev/gpiochip0", Offset: 12, Output: true}},
+		{spec: "relay=17", wantErr: true},
+		{spec: "relay=-1:out", wantErr: true},
+		{spec: "relay=17:both", wantErr: true},
+		{spec: "relay=17:out:open-drain", wantErr: true},
+		{spec: "=17:out", wantErr: true},
+		{spec: "my relay=17:out", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLine(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLine(%q) = %+v, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLine(%q): %v", tt.spec, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseLine(%q) = %+v, want %+v", tt.spec, *got, tt.want)
+		}
+	}
+}
+
+// newSimController returns a controller over one simulated chip, and the
+// chip to drive its inputs.
+func newSimController(t *testing.T, specs ...string) (*Controller, *SimChip) {
+	t.Helper()
+	var lines []*Line
+	for _, spec := range specs {
+		line, err := ParseLine(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	chip := NewSimChip(32)
+	c := NewController(lines, func(string) (Chip, error) { return chip, nil })
+	t.Cleanup(func() { c.Close() })
+	return c, chip
+}
+
+func TestControllerGetSet(t *testing.T) {
+	c, chip := newSimController(t, "relay=17:out", "buzzer=18:out:active-low", "door=27:in")
+
+	if err := c.Set("relay", 1); err != nil {
+		t.Fatal(err)
+	}
+	if chip.Level(17) != 1 {
+		t.Errorf("relay level = %d, want 1", chip.Level(17))
+	}
+	if v, err := c.Get("relay"); err != nil || v != 1 {
+		t.Errorf("Get(relay) = %d, %v; want 1", v, err)
+	}
+
+	// Active-low lines are driven low when on
+	if err := c.Set("buzzer", 1); err != nil {
+		t.Fatal(err)
+	}
+	if chip.Level(18) != 0 {
+		t.Errorf("buzzer level = %d, want 0", chip.Level(18))
+	}
+	if v, _ := c.Get("buzzer"); v != 1 {
+		t.Errorf("Get(buzzer) = %d, want 1", v)
+	}
+
+	chip.Drive(27, 1)
+	if v, err := c.Get("door"); err != nil || v != 1 {
+		t.Errorf("Get(door) = %d, %v; want 1", v, err)
+	}
+
+	if err := c.Set("door", 1); !errors.Is(err, ErrNotOutput) {
+		t.Errorf("Set() of an input error = %v, want ErrNotOutput", err)
+	}
+	if err := c.Set("relay", 2); err == nil {
+		t.Error("Set() of 2 succeeded")
+	}
+	if _, err := c.Get("lamp"); err == nil || !strings.Contains(err.Error(), "unknown GPIO line") {
+		t.Errorf("Get() of an unknown line error = %v", err)
+	}
+}
+
+func TestControllerRequestErrors(t *testing.T) {
+	c, _ := newSimController(t, "far=40:in", "a=5:in", "b=5:in")
+
+	if _, err := c.Get("far"); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("Get() of an offset past the chip error = %v", err)
+	}
+	if _, err := c.Get("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("b"); err == nil || !strings.Contains(err.Error(), "busy") {
+		t.Errorf("Get() of a claimed offset error = %v", err)
+	}
+
+	failing := NewController([]*Line{{Name: "x", Chip: "gpiochip9"}}, func(chip string) (Chip, error) {
+		return nil, errors.New("no such chip")
+	})
+	if _, err := failing.Get("x"); err == nil {
+		t.Error("Get() on a chip that does not open succeeded")
+	}
+}
+
+// waitWatched waits until offset has n watchers, as Watch registers them
+// from its goroutine.
+func waitWatched(t *testing.T, chip *SimChip, offset, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		chip.mu.Lock()
+		got := len(chip.watchers[offset])
+		chip.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("offset %d has %d watchers, want %d", offset, got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestControllerWatch(t *testing.T) {
+	c, chip := newSimController(t, "door=27:in:active-low", "relay=17:out")
+
+	events := make(chan Event, 10)
+	err := c.Watch("door", func(e Event, err error) {
+		if err != nil {
+			t.Errorf("watch failed: %v", err)
+			return
+		}
+		events <- e
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitWatched(t, chip, 27, 1)
+	if !c.Watching("door") {
+		t.Error("Watching(door) = false")
+	}
+
+	chip.Drive(27, 1)
+	chip.Drive(27, 1) // no edge
+	chip.Drive(27, 0)
+	for _, want := range []int{0, 1} {
+		select {
+		case e := <-events:
+			if e.Line != "door" || e.Value != want || e.At.IsZero() {
+				t.Errorf("event %+v, want value %d", e, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no event for value %d", want)
+		}
+	}
+
+	if err := c.Watch("door", func(Event, error) {}); err == nil {
+		t.Error("second Watch() of the same line succeeded")
+	}
+	if err := c.Watch("relay", func(Event, error) {}); err == nil {
+		t.Error("Watch() of an output succeeded")
+	}
+
+	if err := c.Unwatch("door"); err != nil {
+		t.Fatal(err)
+	}
+	waitWatched(t, chip, 27, 0)
+	chip.Drive(27, 1)
+	select {
+	case e := <-events:
+		t.Errorf("event %+v after Unwatch", e)
+	default:
+	}
+	if c.Watching("door") {
+		t.Error("Watching(door) = true after Unwatch")
+	}
+	if err := c.Unwatch("door"); err == nil {
+		t.Error("Unwatch() of a line not being watched succeeded")
+	}
+}
+
+// brokenChip hands out lines whose watch fails at once.
+type brokenChip struct{ *SimChip }
+
+type brokenHandle struct{ Handle }
+
+func (c *brokenChip) Request(offset int, line *Line) (Handle, error) {
+	h, err := c.SimChip.Request(offset, line)
+	return brokenHandle{h}, err
+}
+
+func (brokenHandle) Watch(ctx context.Context, fn func(value int, at time.Time)) error {
+	return errors.New("read failed")
+}
+
+func TestControllerWatchFails(t *testing.T) {
+	chip := &brokenChip{NewSimChip(32)}
+	c := NewController([]*Line{{Name: "door", Chip: DefaultChip, Offset: 27}}, func(string) (Chip, error) {
+		return chip, nil
+	})
+	defer c.Close()
+
+	failed := make(chan error, 1)
+	if err := c.Watch("door", func(e Event, err error) { failed <- err }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-failed:
+		if err == nil || err.Error() != "read failed" {
+			t.Errorf("watch error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the failed watch was not reported")
+	}
+	if c.Watching("door") {
+		t.Error("Watching(door) = true after the watch failed")
+	}
+}
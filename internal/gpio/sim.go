@@ -0,0 +1,150 @@
+package gpio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SimChip is an in-memory chip for running without GPIO hardware. Inputs
+// are driven with Drive, which also delivers edges to watchers.
+type SimChip struct {
+	mu       sync.Mutex
+	values   []int // physical levels
+	claimed  map[int]*simHandle
+	watchers map[int]map[*simWatcher]bool
+}
+
+type simWatcher struct {
+	fn func(value int, at time.Time)
+}
+
+// NewSimChip returns a simulated chip with n lines, all low.
+func NewSimChip(n int) *SimChip {
+	return &SimChip{
+		values:   make([]int, n),
+		claimed:  make(map[int]*simHandle),
+		watchers: make(map[int]map[*simWatcher]bool),
+	}
+}
+
+// SimOpener returns an open function for NewController that hands out one
+// simulated chip of n lines per chip name.
+func SimOpener(n int) func(chip string) (Chip, error) {
+	var mu sync.Mutex
+	chips := make(map[string]*SimChip)
+	return func(chip string) (Chip, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := chips[chip]; !ok {
+			chips[chip] = NewSimChip(n)
+		}
+		return chips[chip], nil
+	}
+}
+
+// Request implements Chip.
+func (c *SimChip) Request(offset int, line *Line) (Handle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if offset < 0 || offset >= len(c.values) {
+		return nil, fmt.Errorf("offset %d out of range (chip has %d lines)", offset, len(c.values))
+	}
+	if _, ok := c.claimed[offset]; ok {
+		return nil, fmt.Errorf("line %d is busy", offset)
+	}
+
+	h := &simHandle{chip: c, offset: offset, activeLow: line.ActiveLow}
+	c.claimed[offset] = h
+	return h, nil
+}
+
+// Close implements Chip.
+func (c *SimChip) Close() error {
+	return nil
+}
+
+// Drive sets the physical level of an input line, as something wired to
+// the pin would, and reports an edge to watchers when it changes.
+func (c *SimChip) Drive(offset, level int) {
+	c.mu.Lock()
+	if offset < 0 || offset >= len(c.values) || c.values[offset] == level {
+		c.mu.Unlock()
+		return
+	}
+	c.values[offset] = level
+
+	value := level
+	if h, ok := c.claimed[offset]; ok {
+		value = h.logical(level)
+	}
+	var watchers []*simWatcher
+	for w := range c.watchers[offset] {
+		watchers = append(watchers, w)
+	}
+	c.mu.Unlock()
+
+	now := time.Now()
+	for _, w := range watchers {
+		w.fn(value, now)
+	}
+}
+
+// Level returns the physical level of a line.
+func (c *SimChip) Level(offset int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[offset]
+}
+
+type simHandle struct {
+	chip      *SimChip
+	offset    int
+	activeLow bool
+}
+
+func (h *simHandle) logical(level int) int {
+	if h.activeLow {
+		return 1 - level
+	}
+	return level
+}
+
+func (h *simHandle) Get() (int, error) {
+	h.chip.mu.Lock()
+	defer h.chip.mu.Unlock()
+	return h.logical(h.chip.values[h.offset]), nil
+}
+
+func (h *simHandle) Set(value int) error {
+	h.chip.mu.Lock()
+	defer h.chip.mu.Unlock()
+	h.chip.values[h.offset] = h.logical(value)
+	return nil
+}
+
+func (h *simHandle) Watch(ctx context.Context, fn func(value int, at time.Time)) error {
+	w := &simWatcher{fn: fn}
+	h.chip.mu.Lock()
+	if h.chip.watchers[h.offset] == nil {
+		h.chip.watchers[h.offset] = make(map[*simWatcher]bool)
+	}
+	h.chip.watchers[h.offset][w] = true
+	h.chip.mu.Unlock()
+
+	<-ctx.Done()
+
+	h.chip.mu.Lock()
+	delete(h.chip.watchers[h.offset], w)
+	h.chip.mu.Unlock()
+	return nil
+}
+
+func (h *simHandle) Close() error {
+	h.chip.mu.Lock()
+	defer h.chip.mu.Unlock()
+	delete(h.chip.claimed, h.offset)
+	return nil
+}
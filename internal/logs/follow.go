@@ -0,0 +1,273 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"mypibot-go/internal/command"
+)
+
+// cursorPrefix starts the line journalctl --show-cursor appends.
+const cursorPrefix = "-- cursor: "
+
+// maxAlertLineLength truncates matched lines in alert messages.
+const maxAlertLineLength = 300
+
+// Rule raises an alert for lines of Source matching Pattern.
+type Rule struct {
+	Source  Source
+	Pattern *regexp.Regexp
+}
+
+// ParseRule parses "source|regex", e.g. "kernel|I/O error" or
+// "/var/log/nginx/error.log|\[crit\]". The regex is case-insensitive.
+func ParseRule(spec string) (*Rule, error) {
+	source, pattern, ok := strings.Cut(spec, "|")
+	source, pattern = strings.TrimSpace(source), strings.TrimSpace(pattern)
+	if !ok || source == "" || pattern == "" {
+		return nil, fmt.Errorf("invalid log alert %q: expected source|regex", spec)
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log alert %q: %w", spec, err)
+	}
+	return &Rule{Source: ParseSource(source), Pattern: re}, nil
+}
+
+func (r *Rule) String() string {
+	return fmt.Sprintf("%s|%s", r.Source, strings.TrimPrefix(r.Pattern.String(), "(?i)"))
+}
+
+// ruleState rate limits one rule's alerts.
+type ruleState struct {
+	lastSent   time.Time
+	suppressed int
+}
+
+// fileState tracks how far a followed file has been read.
+type fileState struct {
+	offset     int64
+	info       os.FileInfo // nil until the file is first found
+	partial    string      // last line, not yet terminated
+	missing    bool        // absent at the last poll, so read from the start when it appears
+	statFailed bool        // a stat error was logged and not yet cleared
+}
+
+// Follower reads new log lines on every poll and reports lines matching its
+// rules, at most one message per rule per interval. Matches in between are
+// counted and mentioned in the next message. It is an alert.EventSource.
+type Follower struct {
+	mu       sync.Mutex
+	runner   command.Runner
+	root     string
+	rules    []*Rule
+	interval time.Duration
+	state    map[*Rule]*ruleState
+	files    map[string]*fileState
+	cursors  map[Source]string
+}
+
+func NewFollower(runner command.Runner, root string, rules []*Rule, interval time.Duration) *Follower {
+	f := &Follower{
+		runner:   runner,
+		root:     root,
+		rules:    rules,
+		interval: interval,
+		state:    make(map[*Rule]*ruleState),
+		files:    make(map[string]*fileState),
+		cursors:  make(map[Source]string),
+	}
+	for _, rule := range rules {
+		f.state[rule] = &ruleState{}
+	}
+	return f
+}
+
+// Events reads the lines added to every followed source since the previous
+// call and returns alerts for matches. The first call only finds the
+// current end of each source.
+func (f *Follower) Events() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Read each source once, however many rules use it
+	lines := make(map[Source][]string)
+	for _, rule := range f.rules {
+		if _, done := lines[rule.Source]; done {
+			continue
+		}
+		newLines, err := f.read(rule.Source)
+		if err != nil {
+			// Logged here so one failing source does not hold back the
+			// alerts of the others
+			log.Printf("Error following %s: %v", rule.Source, err)
+		}
+		lines[rule.Source] = newLines
+	}
+
+	now := time.Now()
+	var events []string
+	for _, rule := range f.rules {
+		state := f.state[rule]
+		var first string
+		matches := 0
+		for _, line := range lines[rule.Source] {
+			if rule.Pattern.MatchString(line) {
+				if matches == 0 {
+					first = line
+				}
+				matches++
+			}
+		}
+		if matches == 0 {
+			continue
+		}
+
+		if !state.lastSent.IsZero() && now.Sub(state.lastSent) < f.interval {
+			state.suppressed += matches
+			continue
+		}
+
+		if runes := []rune(first); len(runes) > maxAlertLineLength {
+			first = string(runes[:maxAlertLineLength]) + "…"
+		}
+		text := fmt.Sprintf("📜 Log alert (%s):\n%s", rule, first)
+		if more := matches - 1 + state.suppressed; more > 0 {
+			text += fmt.Sprintf("\n(+%d more matching lines)", more)
+		}
+		events = append(events, text)
+		state.lastSent = now
+		state.suppressed = 0
+	}
+
+	return events, nil
+}
+
+func (f *Follower) read(source Source) ([]string, error) {
+	if source.Kind == KindFile {
+		return f.readFile(source.Name)
+	}
+	return f.readJournal(source)
+}
+
+// readFile returns the complete lines appended since the previous read,
+// starting over when the file is rotated or truncated. A file that was
+// missing, e.g. between rotations, is read from its start when it appears.
+// Like Tail it reads at most maxTailBytes: when more was appended, the
+// older part is skipped.
+func (f *Follower) readFile(name string) ([]string, error) {
+	path := filepath.Join(f.root, name)
+	state, seen := f.files[name]
+	if !seen {
+		state = &fileState{}
+		f.files[name] = state
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		state.info, state.offset, state.partial = nil, 0, ""
+		state.missing, state.statFailed = true, false
+		return nil, nil
+	}
+	if err != nil {
+		// Logged once rather than on every poll; try again next time
+		if !state.statFailed {
+			log.Printf("Error following %s, retrying: %v", name, err)
+			state.statFailed = true
+		}
+		return nil, nil
+	}
+	state.statFailed = false
+
+	switch {
+	case state.missing:
+		state.missing = false
+	case state.info == nil:
+		// First found: only lines appended from now on are new
+		state.offset, state.info = info.Size(), info
+		return nil, nil
+	case !os.SameFile(state.info, info) || info.Size() < state.offset:
+		state.offset, state.partial = 0, ""
+	}
+	state.info = info
+	if info.Size() == state.offset {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", name, err)
+	}
+	defer file.Close()
+
+	skipped := info.Size() - state.offset - maxTailBytes
+	if skipped > 0 {
+		log.Printf("%s grew by more than %d bytes since the last poll, skipped %d bytes", name, maxTailBytes, skipped)
+		state.offset += skipped
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(file, state.offset, info.Size()-state.offset))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	state.offset += int64(len(data))
+
+	if skipped > 0 {
+		// Reading starts mid-line; drop that line along with what was
+		// carried over from before the gap
+		state.partial = ""
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		} else {
+			data = nil
+		}
+	}
+
+	text := state.partial + strings.ToValidUTF8(string(data), "\uFFFD")
+	lines := strings.Split(text, "\n")
+	state.partial = lines[len(lines)-1]
+	return lines[:len(lines)-1], nil
+}
+
+// readJournal returns the journal entries added since the previous read,
+// tracked with a journal cursor.
+func (f *Follower) readJournal(source Source) ([]string, error) {
+	cursor, seen := f.cursors[source]
+
+	args := []string{"--show-cursor"}
+	if seen && cursor != "" {
+		args = append(args, "--after-cursor="+cursor)
+	} else {
+		args = append(args, "-n", "1")
+	}
+
+	out, err := runJournal(context.Background(), f.runner, source, "cat", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := splitLines(out)
+	if n := len(lines); n > 0 && strings.HasPrefix(lines[n-1], cursorPrefix) {
+		f.cursors[source] = strings.TrimPrefix(lines[n-1], cursorPrefix)
+		lines = lines[:n-1]
+	} else if !seen {
+		// An empty journal has no cursor yet: read from the start next time
+		f.cursors[source] = ""
+	}
+
+	if !seen {
+		return nil, nil
+	}
+	return lines, nil
+}
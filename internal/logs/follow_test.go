@@ -0,0 +1,168 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string // String() of the rule
+		kind    string
+		wantErr bool
+	}{
+		{spec: "kernel|I/O error", want: "kernel|I/O error", kind: KindKernel},
+		{spec: " /var/log/nginx/error.log | \\[crit\\] ", want: "/var/log/nginx/error.log|\\[crit\\]", kind: KindFile},
+		{spec: "nginx|upstream timed out", want: "nginx.service|upstream timed out", kind: KindUnit},
+		{spec: "kernel|a|b", want: "kernel|a|b", kind: KindKernel},
+		{spec: "kernel", wantErr: true},
+		{spec: "|error", wantErr: true},
+		{spec: "kernel|", wantErr: true},
+		{spec: "kernel|(unclosed", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRule(%q) = %v, want an error", tt.spec, rule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tt.spec, err)
+			continue
+		}
+		if rule.String() != tt.want || rule.Source.Kind != tt.kind {
+			t.Errorf("ParseRule(%q) = %s (%s), want %s (%s)", tt.spec, rule, rule.Source.Kind, tt.want, tt.kind)
+		}
+	}
+
+	rule, _ := ParseRule("kernel|I/O Error")
+	if !rule.Pattern.MatchString("blk_update_request: i/o error, dev mmcblk0") {
+		t.Error("rule pattern is case-sensitive")
+	}
+}
+
+const testLog = "/var/log/app.log"
+
+// newFileFollower follows testLog under a temporary root for lines
+// containing "error", and returns the follower and the file's path.
+func newFileFollower(t *testing.T, interval time.Duration) (*Follower, string) {
+	t.Helper()
+	root := t.TempDir()
+	path := filepath.Join(root, testLog)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rule, err := ParseRule(testLog + "|error")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFollower(nil, root, []*Rule{rule}, interval), path
+}
+
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wantEvents checks the alerts of the next poll, one substring per alert.
+func wantEvents(t *testing.T, f *Follower, want ...string) {
+	t.Helper()
+	events, err := f.Events()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Events() = %q, want %d alerts", events, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(events[i], w) {
+			t.Errorf("alert %q, want it to contain %q", events[i], w)
+		}
+	}
+}
+
+func TestFollowerFile(t *testing.T) {
+	f, path := newFileFollower(t, 0)
+
+	// Lines already there at the first poll are not reported
+	appendFile(t, path, "old error\n")
+	wantEvents(t, f)
+
+	appendFile(t, path, "ok\nnew error\nhalf an err")
+	wantEvents(t, f, "new error")
+	// The unterminated line is reported once it is complete
+	appendFile(t, path, "or\n")
+	wantEvents(t, f, "half an error")
+
+	// Truncated in place: read from the start again
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "error after truncation\n")
+	wantEvents(t, f, "error after truncation")
+
+	// Rotated: the new file is read from its start
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "error in the new file, which is much longer than the old one\n")
+	wantEvents(t, f, "error in the new file")
+}
+
+func TestFollowerFileReappears(t *testing.T) {
+	f, path := newFileFollower(t, 0)
+
+	// Not there yet at the first poll, so all of it is new once it appears
+	wantEvents(t, f)
+	appendFile(t, path, "first error\n")
+	wantEvents(t, f, "first error")
+
+	// Missing for a poll between rotations
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	wantEvents(t, f)
+	appendFile(t, path, "error after rotation\n")
+	wantEvents(t, f, "error after rotation")
+}
+
+func TestFollowerRateLimit(t *testing.T) {
+	f, path := newFileFollower(t, time.Hour)
+	wantEvents(t, f)
+
+	appendFile(t, path, "error 1\nerror 2\n")
+	wantEvents(t, f, "error 1\n(+1 more matching lines)")
+
+	// Within the interval matches are only counted
+	appendFile(t, path, "error 3\nerror 4\n")
+	wantEvents(t, f)
+
+	for _, state := range f.state {
+		state.lastSent = state.lastSent.Add(-time.Hour)
+	}
+	appendFile(t, path, "error 5\n")
+	wantEvents(t, f, "error 5\n(+2 more matching lines)")
+
+	for _, state := range f.state {
+		state.lastSent = state.lastSent.Add(-time.Hour)
+	}
+	appendFile(t, path, "error 6\n")
+	events, _ := f.Events()
+	if len(events) != 1 || strings.Contains(events[0], "more matching") {
+		t.Errorf("Events() = %q, want one alert without suppressed matches", events)
+	}
+}
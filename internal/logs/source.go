@@ -0,0 +1,204 @@
+// Package logs reads recent lines from journald and log files, and follows
+// them for lines matching alert rules.
+package logs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/command"
+	"mypibot-go/internal/systemd"
+)
+
+// Source kinds.
+const (
+	KindKernel = "kernel" // the kernel ring buffer, through journalctl -k
+	KindUnit   = "unit"   // a systemd unit's journal
+	KindFile   = "file"   // a plain log file
+)
+
+// maxTailBytes is how much of the end of a file Tail reads. Lines further
+// back are not searched.
+const maxTailBytes = 1 << 20
+
+// maxJournalLines is how many journal lines Tail reads when filtering.
+const maxJournalLines = 5000
+
+const journalTimeout = 30 * time.Second
+
+// Source is where log lines come from.
+type Source struct {
+	Kind string
+	Name string // unit name or absolute file path
+}
+
+// ParseSource reads "kernel", an absolute file path or a unit name ("nginx"
+// meaning nginx.service).
+func ParseSource(s string) Source {
+	switch {
+	case s == KindKernel:
+		return Source{Kind: KindKernel, Name: KindKernel}
+	case strings.HasPrefix(s, "/"):
+		return Source{Kind: KindFile, Name: filepath.Clean(s)}
+	}
+	return Source{Kind: KindUnit, Name: systemd.NormalizeUnit(s)}
+}
+
+func (s Source) String() string {
+	return s.Name
+}
+
+// Reader returns recent lines from an allowlist of sources.
+type Reader struct {
+	runner  command.Runner
+	root    string
+	allowed map[Source]bool
+	sources []Source
+}
+
+// NewReader allows the given sources (see ParseSource). Files are read
+// under root.
+func NewReader(runner command.Runner, root string, sources []string) *Reader {
+	r := &Reader{runner: runner, root: root, allowed: make(map[Source]bool)}
+	for _, s := range sources {
+		source := ParseSource(s)
+		if !r.allowed[source] {
+			r.allowed[source] = true
+			r.sources = append(r.sources, source)
+		}
+	}
+	return r
+}
+
+// Sources returns the allowed sources in configuration order.
+func (r *Reader) Sources() []Source {
+	return r.sources
+}
+
+// Grep compiles a case-insensitive filter. Text that is not a valid
+// regular expression is matched literally.
+func Grep(text string) *regexp.Regexp {
+	if re, err := regexp.Compile("(?i)" + text); err == nil {
+		return re
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+}
+
+// Tail returns up to n of the newest lines of source, oldest first. When
+// filter is not nil only matching lines count.
+func (r *Reader) Tail(ctx context.Context, name string, n int, filter *regexp.Regexp) ([]string, error) {
+	source := ParseSource(name)
+	if !r.allowed[source] {
+		return nil, fmt.Errorf("%s is not in LOG_SOURCES", source)
+	}
+
+	var lines []string
+	var err error
+	if source.Kind == KindFile {
+		lines, err = tailFile(filepath.Join(r.root, source.Name))
+	} else {
+		// Filtering needs more than n lines to find n matches
+		limit := n
+		if filter != nil {
+			limit = maxJournalLines
+		}
+		lines, err = r.journal(ctx, source, "-n", strconv.Itoa(limit))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if filter != nil {
+		matched := lines[:0]
+		for _, line := range lines {
+			if filter.MatchString(line) {
+				matched = append(matched, line)
+			}
+		}
+		lines = matched
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// journal runs journalctl for source with extra arguments and returns the
+// output lines.
+func (r *Reader) journal(ctx context.Context, source Source, args ...string) ([]string, error) {
+	out, err := runJournal(ctx, r.runner, source, "short-iso", args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+func runJournal(ctx context.Context, runner command.Runner, source Source, format string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, journalTimeout)
+	defer cancel()
+
+	base := []string{"--no-pager", "-q", "-o", format}
+	if source.Kind == KindKernel {
+		base = append(base, "-k")
+	} else {
+		base = append(base, "-u", source.Name)
+	}
+
+	out, err := runner.Run(ctx, "journalctl", append(base, args...)...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return nil, fmt.Errorf("error reading journal for %s: %w: %s", source, err, msg)
+		}
+		return nil, fmt.Errorf("error reading journal for %s: %w", source, err)
+	}
+	return out, nil
+}
+
+// tailFile returns the lines in the last maxTailBytes of a file.
+func tailFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening log file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading log file: %w", err)
+	}
+
+	offset := info.Size() - maxTailBytes
+	if offset < 0 {
+		offset = 0
+	}
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return nil, fmt.Errorf("error reading log file: %w", err)
+	}
+
+	// Drop the partial first line when starting mid-file
+	if offset > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return splitLines(data), nil
+}
+
+// splitLines splits output into lines, replacing invalid UTF-8, which
+// Telegram rejects.
+func splitLines(data []byte) []string {
+	text := strings.ToValidUTF8(strings.TrimRight(string(data), "\n"), "\uFFFD")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
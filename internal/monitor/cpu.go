@@ -0,0 +1,170 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/load"
+)
+
+// CPUStats is CPU usage over a sampling window, in percent.
+type CPUStats struct {
+	Total   float64
+	PerCore []float64
+	IOWait  float64
+	Steal   float64
+	Window  time.Duration
+}
+
+// cpuSnapshot is one reading of the per-core time counters.
+type cpuSnapshot struct {
+	at    time.Time
+	times []cpu.TimesStat
+}
+
+// sampleCPU records the current counters, keeping the previous reading so
+// the sampler always has a window to compute usage over.
+func (m *Monitor) sampleCPU() error {
+	times, err := cpu.Times(true)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cpuPrev = m.cpuLast
+	m.cpuLast = &cpuSnapshot{at: time.Now(), times: times}
+	return nil
+}
+
+// CPUStats returns usage over the background sampler's latest window. If
+// the sampler is not running or has gone stale it measures over one second
+// instead.
+func (m *Monitor) CPUStats() (*CPUStats, error) {
+	m.mu.Lock()
+	prev, last := m.cpuPrev, m.cpuLast
+	m.mu.Unlock()
+
+	if prev == nil || last == nil || time.Since(last.at) > 3*SampleInterval {
+		first, err := cpu.Times(true)
+		if err != nil {
+			return nil, fmt.Errorf("error getting CPU times: %w", err)
+		}
+		start := time.Now()
+		time.Sleep(time.Second)
+		second, err := cpu.Times(true)
+		if err != nil {
+			return nil, fmt.Errorf("error getting CPU times: %w", err)
+		}
+		prev = &cpuSnapshot{at: start, times: first}
+		last = &cpuSnapshot{at: time.Now(), times: second}
+	}
+
+	return cpuUsage(prev, last), nil
+}
+
+// cpuUsage computes usage between two snapshots of per-core counters.
+func cpuUsage(prev, last *cpuSnapshot) *CPUStats {
+	stats := &CPUStats{Window: last.at.Sub(prev.at)}
+
+	var totalDelta, busyDelta, iowaitDelta, stealDelta float64
+	for i := range last.times {
+		if i >= len(prev.times) {
+			break
+		}
+		a, b := prev.times[i], last.times[i]
+
+		total := b.Total() - a.Total()
+		idle := (b.Idle + b.Iowait) - (a.Idle + a.Iowait)
+		busy := total - idle
+
+		core := 0.0
+		if total > 0 {
+			core = clampPercent(busy / total * 100)
+		}
+		stats.PerCore = append(stats.PerCore, core)
+
+		totalDelta += total
+		busyDelta += busy
+		iowaitDelta += b.Iowait - a.Iowait
+		stealDelta += b.Steal - a.Steal
+	}
+
+	if totalDelta > 0 {
+		stats.Total = clampPercent(busyDelta / totalDelta * 100)
+		stats.IOWait = clampPercent(iowaitDelta / totalDelta * 100)
+		stats.Steal = clampPercent(stealDelta / totalDelta * 100)
+	}
+	return stats
+}
+
+func clampPercent(p float64) float64 {
+	if p < 0 {
+		return 0
+	}
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+// cpuFrequency returns the current and maximum frequency of cpu0 in MHz,
+// read from cpufreq under root.
+func cpuFrequency(root string) (current, max float64, err error) {
+	dir := filepath.Join(root, "sys/devices/system/cpu/cpu0/cpufreq")
+
+	read := func(name string) (float64, error) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return 0, err
+		}
+		khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			return 0, err
+		}
+		return khz / 1000, nil
+	}
+
+	if current, err = read("scaling_cur_freq"); err != nil {
+		return 0, 0, err
+	}
+	if max, err = read("cpuinfo_max_freq"); err != nil {
+		return 0, 0, err
+	}
+	return current, max, nil
+}
+
+// formatCPUStats renders the CPU section of /status.
+func (m *Monitor) formatCPUStats() (string, error) {
+	stats, err := m.CPUStats()
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("CPU Usage: %.1f%% (over %s)\n", stats.Total, stats.Window.Round(100*time.Millisecond)))
+
+	if len(stats.PerCore) > 1 {
+		cores := make([]string, len(stats.PerCore))
+		for i, p := range stats.PerCore {
+			cores[i] = fmt.Sprintf("%.0f%%", p)
+		}
+		result.WriteString("Per core: " + strings.Join(cores, " ") + "\n")
+	}
+	result.WriteString(fmt.Sprintf("iowait: %.1f%%, steal: %.1f%%\n", stats.IOWait, stats.Steal))
+
+	if avg, err := load.Avg(); err == nil {
+		result.WriteString(fmt.Sprintf("Load average: %.2f %.2f %.2f\n", avg.Load1, avg.Load5, avg.Load15))
+	}
+
+	if current, max, err := cpuFrequency(m.root); err == nil {
+		result.WriteString(fmt.Sprintf("CPU Frequency: %.0f MHz (max %.0f MHz)\n", current, max))
+	}
+
+	return result.String(), nil
+}
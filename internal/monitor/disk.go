@@ -0,0 +1,291 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// pseudoFilesystems are filesystem types that do not live on a disk and are
+// left out of disk reports.
+var pseudoFilesystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true,
+	"cgroup2": true, "configfs": true, "debugfs": true, "devpts": true,
+	"devtmpfs": true, "efivarfs": true, "fusectl": true, "hugetlbfs": true,
+	"mqueue": true, "nsfs": true, "proc": true, "pstore": true,
+	"ramfs": true, "rpc_pipefs": true, "securityfs": true, "squashfs": true,
+	"sysfs": true, "tmpfs": true, "tracefs": true,
+}
+
+// DiskUsage is the usage of one mounted filesystem.
+type DiskUsage struct {
+	Device     string
+	Mountpoint string
+	Fstype     string
+	Usage      *disk.UsageStat
+}
+
+// Sample is one stored reading of a metric.
+type Sample struct {
+	At    time.Time
+	Value float64
+}
+
+// DiskIORate is the throughput of one block device over a sampling window.
+type DiskIORate struct {
+	ReadBytes, WriteBytes float64 // per second
+	ReadOps, WriteOps     float64 // per second
+}
+
+type diskSnapshot struct {
+	at       time.Time
+	counters map[string]disk.IOCountersStat
+}
+
+// DiskUsages returns the usage of every real filesystem, skipping pseudo
+// filesystems and further mounts of an already listed device.
+func (m *Monitor) DiskUsages() ([]DiskUsage, error) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return nil, fmt.Errorf("error getting disk partitions: %w", err)
+	}
+
+	var usages []DiskUsage
+	seen := make(map[string]bool)
+	for _, partition := range partitions {
+		if pseudoFilesystems[partition.Fstype] || seen[partition.Device] {
+			continue
+		}
+		usage, err := disk.Usage(partition.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+		seen[partition.Device] = true
+		usages = append(usages, DiskUsage{
+			Device:     partition.Device,
+			Mountpoint: partition.Mountpoint,
+			Fstype:     partition.Fstype,
+			Usage:      usage,
+		})
+	}
+	return usages, nil
+}
+
+// isPhysicalDisk reports whether name is a whole block device rather than
+// a partition or a virtual device like a loop or zram device.
+func (m *Monitor) isPhysicalDisk(name string) bool {
+	for _, prefix := range []string{"loop", "ram", "zram", "dm-", "md"} {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	_, err := os.Stat(filepath.Join(m.root, "sys/block", name))
+	return err == nil
+}
+
+func (m *Monitor) sampleDisk() error {
+	counters, err := disk.IOCounters()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.diskPrev = m.diskLast
+	m.diskLast = &diskSnapshot{at: time.Now(), counters: counters}
+	return nil
+}
+
+// diskRates returns per-device throughput and IOPS over the sampler's
+// latest window (or a fresh one-second window if the sampler is not
+// running).
+func (m *Monitor) diskRates() (map[string]DiskIORate, error) {
+	m.mu.Lock()
+	prev, last := m.diskPrev, m.diskLast
+	m.mu.Unlock()
+
+	if prev == nil || last == nil || time.Since(last.at) > 3*SampleInterval {
+		first, err := disk.IOCounters()
+		if err != nil {
+			return nil, fmt.Errorf("error getting disk I/O counters: %w", err)
+		}
+		start := time.Now()
+		time.Sleep(time.Second)
+		second, err := disk.IOCounters()
+		if err != nil {
+			return nil, fmt.Errorf("error getting disk I/O counters: %w", err)
+		}
+		prev = &diskSnapshot{at: start, counters: first}
+		last = &diskSnapshot{at: time.Now(), counters: second}
+	}
+
+	seconds := last.at.Sub(prev.at).Seconds()
+	rates := make(map[string]DiskIORate)
+	for name, b := range last.counters {
+		a, ok := prev.counters[name]
+		if !ok || seconds <= 0 || !m.isPhysicalDisk(name) ||
+			b.ReadBytes < a.ReadBytes || b.WriteBytes < a.WriteBytes {
+			continue
+		}
+		rates[name] = DiskIORate{
+			ReadBytes:  float64(b.ReadBytes-a.ReadBytes) / seconds,
+			WriteBytes: float64(b.WriteBytes-a.WriteBytes) / seconds,
+			ReadOps:    float64(b.ReadCount-a.ReadCount) / seconds,
+			WriteOps:   float64(b.WriteCount-a.WriteCount) / seconds,
+		}
+	}
+	return rates, nil
+}
+
+// ParseEMMCLifeTime parses an eMMC life_time file ("0x01 0x02"): the
+// estimated share of the device's life used, in 10% steps, for the two
+// memory types. A value of 0x0b means the estimate has been exceeded.
+func ParseEMMCLifeTime(data string) (typeA, typeB int, err error) {
+	fields := strings.Fields(data)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected life_time %q", strings.TrimSpace(data))
+	}
+	a, errA := strconv.ParseInt(fields[0], 0, 32)
+	b, errB := strconv.ParseInt(fields[1], 0, 32)
+	if errA != nil || errB != nil {
+		return 0, 0, fmt.Errorf("unexpected life_time %q", strings.TrimSpace(data))
+	}
+	return int(a), int(b), nil
+}
+
+// lifeTimeLabel describes an eMMC life time estimate.
+func lifeTimeLabel(v int) string {
+	switch {
+	case v == 0:
+		return "unknown"
+	case v >= 0x0b:
+		return "exceeded"
+	default:
+		return fmt.Sprintf("%d-%d%% used", (v-1)*10, v*10)
+	}
+}
+
+// preEOLLabels are the values of the eMMC pre_eol_info file, which tracks
+// how many reserved blocks have been consumed.
+var preEOLLabels = map[int64]string{
+	1: "normal",
+	2: "warning (80% of reserved blocks used)",
+	3: "urgent (90% of reserved blocks used)",
+}
+
+// diskWear describes the wear indicators sysfs exposes for a block device.
+// Only eMMC devices report them; SD cards usually do not.
+func (m *Monitor) diskWear(name string) string {
+	dir := filepath.Join("sys/block", name, "device")
+
+	var parts []string
+	if a, b, err := ParseEMMCLifeTime(readFile(m.root, filepath.Join(dir, "life_time"))); err == nil {
+		parts = append(parts, fmt.Sprintf("life time A %s, B %s", lifeTimeLabel(a), lifeTimeLabel(b)))
+	}
+	if v, err := strconv.ParseInt(strings.TrimSpace(readFile(m.root, filepath.Join(dir, "pre_eol_info"))), 0, 32); err == nil {
+		if label, ok := preEOLLabels[v]; ok {
+			parts = append(parts, "pre-EOL "+label)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ProjectFull fits a line through used-bytes samples and returns how long
+// until free space runs out at that rate. ok is false when there is too
+// little history or usage is not growing.
+func ProjectFull(samples []Sample, free uint64) (remaining time.Duration, perDay float64, ok bool) {
+	if len(samples) < 3 {
+		return 0, 0, false
+	}
+	sorted := append([]Sample(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+	if sorted[len(sorted)-1].At.Sub(sorted[0].At) < 12*time.Hour {
+		return 0, 0, false
+	}
+
+	// Least squares slope in bytes per second
+	origin := sorted[0].At
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range sorted {
+		x := s.At.Sub(origin).Seconds()
+		sumX += x
+		sumY += s.Value
+		sumXY += x * s.Value
+		sumXX += x * x
+	}
+	n := float64(len(sorted))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	if slope <= 0 {
+		return 0, slope * 86400, false
+	}
+
+	return time.Duration(float64(free) / slope * float64(time.Second)), slope * 86400, true
+}
+
+// GetDiskUsage reports space and inode usage per filesystem, I/O rates
+// and wear per device and, given the stored used-bytes history per
+// mountpoint, when each filesystem will be full.
+func (m *Monitor) GetDiskUsage(history map[string][]Sample) (string, error) {
+	usages, err := m.DiskUsages()
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString("Disk Usage:\n")
+
+	for _, d := range usages {
+		u := d.Usage
+		result.WriteString(fmt.Sprintf("\n%s (%s, %s)\n", d.Mountpoint, d.Device, d.Fstype))
+		result.WriteString(fmt.Sprintf("  Used: %.1f GB / %.1f GB (%.1f%%), %.1f GB free\n",
+			float64(u.Used)/1024/1024/1024,
+			float64(u.Total)/1024/1024/1024,
+			u.UsedPercent,
+			float64(u.Free)/1024/1024/1024,
+		))
+		if u.InodesTotal > 0 {
+			result.WriteString(fmt.Sprintf("  Inodes: %d / %d (%.1f%%)\n",
+				u.InodesUsed, u.InodesTotal, u.InodesUsedPercent))
+		}
+
+		remaining, perDay, ok := ProjectFull(history[d.Mountpoint], u.Free)
+		switch {
+		case ok && remaining < 365*24*time.Hour:
+			result.WriteString(fmt.Sprintf("  Growing %s/day, full in ~%.0f days\n",
+				formatBytes(uint64(perDay)), remaining.Hours()/24))
+		case ok:
+			result.WriteString(fmt.Sprintf("  Growing %s/day, over a year until full\n",
+				formatBytes(uint64(perDay))))
+		}
+	}
+
+	if rates, err := m.diskRates(); err == nil && len(rates) > 0 {
+		names := make([]string, 0, len(rates))
+		for name := range rates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		result.WriteString("\nI/O:\n")
+		for _, name := range names {
+			r := rates[name]
+			result.WriteString(fmt.Sprintf("  %s: read %s/s (%.0f IOPS), write %s/s (%.0f IOPS)\n",
+				name, formatBytes(uint64(r.ReadBytes)), r.ReadOps, formatBytes(uint64(r.WriteBytes)), r.WriteOps))
+			if wear := m.diskWear(name); wear != "" {
+				result.WriteString(fmt.Sprintf("    Wear: %s\n", wear))
+			}
+		}
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,461 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hardware is a snapshot of what the machine is made of, read from procfs,
+// sysfs and the device tree.
+type Hardware struct {
+	Model    string // e.g. "Raspberry Pi Zero 2 W Rev 1.0"
+	Revision string // board revision code from /proc/cpuinfo, e.g. "902120"
+	Board    *BoardRevision
+	Serial   string
+	SoC      string // e.g. "BCM2837"
+	CPU      string // e.g. "Cortex-A53"
+	Cores    int
+
+	MemTotal   uint64 // bytes usable by the kernel
+	Kernel     string
+	OS         string
+	BootDevice string // e.g. "mmcblk0p2 (SD card)"
+	USB        []USBDevice
+}
+
+// BoardRevision is a decoded new-style Raspberry Pi revision code. Old-style
+// codes only fill Type and Memory.
+type BoardRevision struct {
+	Type         string
+	Processor    string
+	Memory       string
+	Manufacturer string
+	Revision     int // PCB revision, e.g. 0 for 1.0
+}
+
+// USBDevice is one device under /sys/bus/usb/devices.
+type USBDevice struct {
+	Bus          string // sysfs name, e.g. "1-1.2"
+	VendorID     string
+	ProductID    string
+	Manufacturer string
+	Product      string
+	Speed        string // Mbit/s, e.g. "480"
+}
+
+var boardTypes = map[uint32]string{
+	0x00: "A", 0x01: "B", 0x02: "A+", 0x03: "B+", 0x04: "2B", 0x05: "Alpha",
+	0x06: "CM1", 0x08: "3B", 0x09: "Zero", 0x0a: "CM3", 0x0c: "Zero W",
+	0x0d: "3B+", 0x0e: "3A+", 0x10: "CM3+", 0x11: "4B", 0x12: "Zero 2 W",
+	0x13: "400", 0x14: "CM4", 0x15: "CM4S", 0x17: "5", 0x18: "CM5",
+	0x19: "500", 0x1a: "CM5 Lite",
+}
+
+var boardProcessors = []string{"BCM2835", "BCM2836", "BCM2837", "BCM2711", "BCM2712"}
+
+var boardManufacturers = []string{"Sony UK", "Egoman", "Embest", "Sony Japan", "Embest", "Stadium"}
+
+var boardMemory = []string{"256MB", "512MB", "1GB", "2GB", "4GB", "8GB", "16GB"}
+
+// oldBoardRevisions covers the codes used before the bit-field scheme, by
+// the original Pi 1 boards.
+var oldBoardRevisions = map[uint32]BoardRevision{
+	0x0002: {Type: "B", Memory: "256MB"},
+	0x0003: {Type: "B", Memory: "256MB"},
+	0x0004: {Type: "B", Memory: "256MB"},
+	0x0005: {Type: "B", Memory: "256MB"},
+	0x0006: {Type: "B", Memory: "256MB"},
+	0x0007: {Type: "A", Memory: "256MB"},
+	0x0008: {Type: "A", Memory: "256MB"},
+	0x0009: {Type: "A", Memory: "256MB"},
+	0x000d: {Type: "B", Memory: "512MB"},
+	0x000e: {Type: "B", Memory: "512MB"},
+	0x000f: {Type: "B", Memory: "512MB"},
+	0x0010: {Type: "B+", Memory: "512MB"},
+	0x0011: {Type: "CM1", Memory: "512MB"},
+	0x0012: {Type: "A+", Memory: "256MB"},
+	0x0013: {Type: "B+", Memory: "512MB"},
+	0x0014: {Type: "CM1", Memory: "512MB"},
+	0x0015: {Type: "A+", Memory: "256MB"},
+}
+
+// ParseBoardRevision decodes a Raspberry Pi revision code such as "a02082"
+// or "0x902120".
+func ParseBoardRevision(code string) (*BoardRevision, error) {
+	code = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(code)), "0x")
+	value, err := strconv.ParseUint(code, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision code %q", code)
+	}
+	v := uint32(value)
+
+	if v&(1<<23) == 0 {
+		// Old-style codes get a 1 in front when the warranty bit is set
+		rev, ok := oldBoardRevisions[v&0xffffff]
+		if !ok {
+			return nil, fmt.Errorf("unknown revision code %q", code)
+		}
+		return &rev, nil
+	}
+
+	rev := &BoardRevision{Revision: int(v & 0xf)}
+	rev.Type = boardTypes[(v>>4)&0xff]
+	if rev.Type == "" {
+		rev.Type = fmt.Sprintf("unknown (0x%x)", (v>>4)&0xff)
+	}
+	rev.Processor = lookup(boardProcessors, (v>>12)&0xf)
+	rev.Manufacturer = lookup(boardManufacturers, (v>>16)&0xf)
+	rev.Memory = lookup(boardMemory, (v>>20)&0x7)
+	return rev, nil
+}
+
+func lookup(names []string, i uint32) string {
+	if int(i) < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("unknown (%d)", i)
+}
+
+// cpuParts names the ARM cores by the "CPU part" field of /proc/cpuinfo.
+var cpuParts = map[string]string{
+	"0xb76": "ARM1176",
+	"0xc07": "Cortex-A7",
+	"0xd03": "Cortex-A53",
+	"0xd08": "Cortex-A72",
+	"0xd0b": "Cortex-A76",
+}
+
+// ParseCPUInfo reads the fields /hw uses from /proc/cpuinfo: the board's
+// Hardware, Revision, Serial and Model, the core type and the core count.
+// Per-core fields are taken from the first core.
+func ParseCPUInfo(data string, hw *Hardware) {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "processor":
+			hw.Cores++
+		case "Hardware":
+			hw.SoC = value
+		case "Revision":
+			hw.Revision = value
+		case "Serial":
+			hw.Serial = value
+		case "Model":
+			if hw.Model == "" {
+				hw.Model = value
+			}
+		case "CPU part":
+			if hw.CPU == "" {
+				hw.CPU = cpuParts[value]
+			}
+		case "model name":
+			// x86, and older ARM kernels ("ARMv7 Processor rev 4 (v7l)")
+			if hw.CPU == "" {
+				hw.CPU = value
+			}
+		}
+	}
+}
+
+// ParseOSRelease returns PRETTY_NAME from an os-release file, or NAME and
+// VERSION when it is missing.
+func ParseOSRelease(data string) string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+		fields[key] = value
+	}
+
+	if pretty := fields["PRETTY_NAME"]; pretty != "" {
+		return pretty
+	}
+	return strings.TrimSpace(fields["NAME"] + " " + fields["VERSION"])
+}
+
+// readDeviceTree reads a device tree property under root. /proc/device-tree
+// is an absolute symlink, so the sysfs path is tried as well for when root
+// is a host mount.
+func readDeviceTree(root, name string) string {
+	for _, dir := range []string{"proc/device-tree", "sys/firmware/devicetree/base"} {
+		if data := readFile(root, filepath.Join(dir, name)); data != "" {
+			return data
+		}
+	}
+	return ""
+}
+
+// deviceTreeString trims the NUL terminator of a device tree string.
+func deviceTreeString(data string) string {
+	return strings.TrimSpace(strings.TrimRight(data, "\x00"))
+}
+
+// socFromCompatible picks the SoC from a device tree "compatible" list,
+// e.g. "raspberrypi,3-model-b\x00brcm,bcm2837\x00" gives "BCM2837".
+func socFromCompatible(data string) string {
+	entries := strings.Split(strings.TrimRight(data, "\x00"), "\x00")
+	for i := len(entries) - 1; i >= 0; i-- {
+		if _, chip, ok := strings.Cut(entries[i], "brcm,"); ok && chip != "" {
+			return strings.ToUpper(chip)
+		}
+	}
+	return ""
+}
+
+// ReadHardware collects the hardware inventory under root. Missing files
+// leave their fields empty.
+func ReadHardware(root string) *Hardware {
+	hw := &Hardware{}
+
+	if model := deviceTreeString(readDeviceTree(root, "model")); model != "" {
+		hw.Model = model
+	}
+
+	ParseCPUInfo(readFile(root, "proc/cpuinfo"), hw)
+	if hw.Serial == "" {
+		hw.Serial = deviceTreeString(readDeviceTree(root, "serial-number"))
+	}
+	if hw.Revision != "" {
+		if rev, err := ParseBoardRevision(hw.Revision); err == nil {
+			hw.Board = rev
+		}
+	}
+
+	// The kernel's Hardware line says BCM2835 on every Pi, so the device
+	// tree and the revision code are trusted first
+	if soc := socFromCompatible(readDeviceTree(root, "compatible")); soc != "" {
+		hw.SoC = soc
+	} else if hw.Board != nil && hw.Board.Processor != "" {
+		hw.SoC = hw.Board.Processor
+	}
+
+	if info, err := ParseMeminfo(readFile(root, "proc/meminfo")); err == nil {
+		hw.MemTotal = info["MemTotal"]
+	}
+	hw.Kernel = strings.TrimSpace(readFile(root, "proc/sys/kernel/osrelease"))
+
+	osRelease := readFile(root, "etc/os-release")
+	if osRelease == "" {
+		osRelease = readFile(root, "usr/lib/os-release")
+	}
+	hw.OS = ParseOSRelease(osRelease)
+
+	hw.BootDevice = bootDevice(root)
+	hw.USB = ReadUSBDevices(root)
+	return hw
+}
+
+// ParseMeminfo parses /proc/meminfo into bytes per field.
+func ParseMeminfo(data string) (map[string]uint64, error) {
+	info := make(map[string]uint64)
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		info[key] = n
+	}
+	if len(info) == 0 {
+		return nil, fmt.Errorf("no fields in meminfo")
+	}
+	return info, nil
+}
+
+// bootDevice finds the block device the root filesystem lives on, from the
+// mount table or, when that only says /dev/root, from the kernel command
+// line, and describes what kind of storage it is.
+func bootDevice(root string) string {
+	var device string
+	for _, line := range strings.Split(readFile(root, "proc/mounts"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[1] == "/" && fields[2] != "rootfs" {
+			device = fields[0]
+			if fields[2] == "nfs" || fields[2] == "nfs4" {
+				return device + " (network)"
+			}
+		}
+	}
+
+	if device == "" || device == "/dev/root" {
+		for _, arg := range strings.Fields(readFile(root, "proc/cmdline")) {
+			if value, ok := strings.CutPrefix(arg, "root="); ok {
+				device = value
+			}
+		}
+	}
+	if device == "" {
+		return ""
+	}
+	if device == "/dev/nfs" {
+		return "NFS (network)"
+	}
+
+	// PARTUUID=... and friends resolve through the /dev/disk symlinks
+	for prefix, dir := range map[string]string{
+		"PARTUUID=": "dev/disk/by-partuuid",
+		"UUID=":     "dev/disk/by-uuid",
+		"LABEL=":    "dev/disk/by-label",
+	} {
+		if id, ok := strings.CutPrefix(device, prefix); ok {
+			target, err := os.Readlink(filepath.Join(root, dir, id))
+			if err != nil {
+				return device
+			}
+			device = filepath.Base(target)
+		}
+	}
+
+	name := strings.TrimPrefix(device, "/dev/")
+	if kind := storageKind(root, name); kind != "" {
+		return fmt.Sprintf("%s (%s)", name, kind)
+	}
+	return name
+}
+
+// storageKind describes the disk a partition such as mmcblk0p2, sda1 or
+// nvme0n1p2 belongs to.
+func storageKind(root, partition string) string {
+	switch {
+	case strings.HasPrefix(partition, "mmcblk"):
+		disk, _, _ := strings.Cut(partition, "p")
+		if strings.TrimSpace(readFile(root, filepath.Join("sys/block", disk, "device/type"))) == "MMC" {
+			return "eMMC"
+		}
+		return "SD card"
+	case strings.HasPrefix(partition, "nvme"):
+		return "NVMe"
+	case strings.HasPrefix(partition, "sd"):
+		disk := strings.TrimRight(partition, "0123456789")
+		target, err := filepath.EvalSymlinks(filepath.Join(root, "sys/block", disk))
+		if err == nil && strings.Contains(target, "/usb") {
+			return "USB"
+		}
+		return "SCSI/SATA"
+	}
+	return ""
+}
+
+// ReadUSBDevices lists the USB devices under root's sysfs, leaving out root
+// hubs and interfaces.
+func ReadUSBDevices(root string) []USBDevice {
+	dir := filepath.Join(root, "sys/bus/usb/devices")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var devices []USBDevice
+	for _, entry := range entries {
+		name := entry.Name()
+		// "usb1" is a root hub, "1-1.2:1.0" an interface of device 1-1.2
+		if strings.HasPrefix(name, "usb") || strings.Contains(name, ":") {
+			continue
+		}
+
+		attr := func(file string) string {
+			return strings.TrimSpace(readFile(root, filepath.Join("sys/bus/usb/devices", name, file)))
+		}
+		vendor := attr("idVendor")
+		if vendor == "" {
+			continue
+		}
+		devices = append(devices, USBDevice{
+			Bus:          name,
+			VendorID:     vendor,
+			ProductID:    attr("idProduct"),
+			Manufacturer: attr("manufacturer"),
+			Product:      attr("product"),
+			Speed:        attr("speed"),
+		})
+	}
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Bus < devices[j].Bus })
+	return devices
+}
+
+// GetHardwareInfo reports the board, SoC, memory, software versions, boot
+// device and USB devices.
+func (m *Monitor) GetHardwareInfo() (string, error) {
+	hw := ReadHardware(m.root)
+	if hw.Model == "" && hw.CPU == "" && hw.Kernel == "" {
+		return "", fmt.Errorf("error reading hardware information from %s", m.root)
+	}
+
+	var result strings.Builder
+	result.WriteString("Hardware:\n")
+	field := func(label, value string) {
+		if value != "" {
+			result.WriteString(fmt.Sprintf("%s: %s\n", label, value))
+		}
+	}
+
+	field("Model", hw.Model)
+	if hw.Board != nil {
+		board := "Pi " + hw.Board.Type
+		if hw.Board.Manufacturer != "" {
+			board += fmt.Sprintf(" rev 1.%d, made by %s", hw.Board.Revision, hw.Board.Manufacturer)
+		}
+		field("Revision", fmt.Sprintf("%s (%s, %s)", hw.Revision, board, hw.Board.Memory))
+	} else {
+		field("Revision", hw.Revision)
+	}
+	field("Serial", hw.Serial)
+	field("SoC", hw.SoC)
+	if hw.CPU != "" && hw.Cores > 0 {
+		field("CPU", fmt.Sprintf("%d × %s", hw.Cores, hw.CPU))
+	} else {
+		field("CPU", hw.CPU)
+	}
+	if hw.MemTotal > 0 {
+		field("Memory", fmt.Sprintf("%d MB usable", hw.MemTotal/1024/1024))
+	}
+	field("Kernel", hw.Kernel)
+	field("OS", hw.OS)
+	field("Boot device", hw.BootDevice)
+
+	if len(hw.USB) > 0 {
+		result.WriteString("\nUSB devices:\n")
+		for _, dev := range hw.USB {
+			name := strings.TrimSpace(dev.Manufacturer + " " + dev.Product)
+			if name == "" {
+				name = "unknown device"
+			}
+			result.WriteString(fmt.Sprintf("• %s [%s:%s]", name, dev.VendorID, dev.ProductID))
+			if dev.Speed != "" {
+				result.WriteString(fmt.Sprintf(" %s Mbit/s", dev.Speed))
+			}
+			result.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(result.String(), "\n"), nil
+}
@@ -0,0 +1,219 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const zero2CPUInfo = `processor	: 0
+BogoMIPS	: 38.40
+Features	: fp asimd evtstrm crc32 cpuid
+CPU implementer	: 0x41
+CPU part	: 0xd03
+
+processor	: 1
+CPU part	: 0xd03
+
+processor	: 2
+CPU part	: 0xd03
+
+processor	: 3
+CPU part	: 0xd03
+
+Hardware	: BCM2835
+Revision	: 902120
+Serial		: 00000000a1b2c3d4
+Model		: Raspberry Pi Zero 2 W Rev 1.0
+`
+
+// writeFixture creates files under root, relative paths to contents.
+func writeFixture(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func symlink(t *testing.T, target, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseBoardRevision(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    BoardRevision
+		wantErr bool
+	}{
+		{
+			code: "902120",
+			want: BoardRevision{Type: "Zero 2 W", Processor: "BCM2837", Memory: "512MB", Manufacturer: "Sony UK"},
+		},
+		{
+			code: "0xa22082",
+			want: BoardRevision{Type: "3B", Processor: "BCM2837", Memory: "1GB", Manufacturer: "Embest", Revision: 2},
+		},
+		{
+			code: "d04171",
+			want: BoardRevision{Type: "5", Processor: "BCM2712", Memory: "8GB", Manufacturer: "Sony UK", Revision: 1},
+		},
+		{code: "0010", want: BoardRevision{Type: "B+", Memory: "512MB"}},
+		{code: "1000002", want: BoardRevision{Type: "B", Memory: "256MB"}}, // warranty bit
+		{code: "0001", wantErr: true},
+		{code: "pi", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBoardRevision(tt.code)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseBoardRevision(%q) = %+v, want an error", tt.code, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBoardRevision(%q): %v", tt.code, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("ParseBoardRevision(%q) = %+v, want %+v", tt.code, *got, tt.want)
+		}
+	}
+}
+
+func TestReadHardware(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"proc/cpuinfo":                            zero2CPUInfo,
+		"sys/firmware/devicetree/base/model":      "Raspberry Pi Zero 2 W Rev 1.0\x00",
+		"sys/firmware/devicetree/base/compatible": "raspberrypi,model-zero-2-w\x00brcm,bcm2837\x00",
+		"proc/meminfo":                            "MemTotal:         439592 kB\nMemFree:           81236 kB\n",
+		"proc/sys/kernel/osrelease":               "6.6.51+rpt-rpi-v8\n",
+		"usr/lib/os-release":                      "PRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\nNAME=\"Debian GNU/Linux\"\n",
+		"proc/mounts":                             "/dev/root / ext4 rw,noatime 0 0\nproc /proc proc rw 0 0\n",
+		"proc/cmdline":                            "console=tty1 root=PARTUUID=4e639091-02 rootfstype=ext4 fsck.repair=yes\n",
+		"sys/block/mmcblk0/device/type":           "SD\n",
+
+		"sys/bus/usb/devices/usb1/idVendor":           "1d6b\n",
+		"sys/bus/usb/devices/1-1/idVendor":            "0bda\n",
+		"sys/bus/usb/devices/1-1/idProduct":           "8153\n",
+		"sys/bus/usb/devices/1-1/manufacturer":        "Realtek\n",
+		"sys/bus/usb/devices/1-1/product":             "USB 10/100/1000 LAN\n",
+		"sys/bus/usb/devices/1-1/speed":               "480\n",
+		"sys/bus/usb/devices/1-1:1.0/bInterfaceClass": "ff\n",
+	})
+	symlink(t, "../../mmcblk0p2", filepath.Join(root, "dev/disk/by-partuuid/4e639091-02"))
+
+	hw := ReadHardware(root)
+	want := &Hardware{
+		Model:      "Raspberry Pi Zero 2 W Rev 1.0",
+		Revision:   "902120",
+		Board:      &BoardRevision{Type: "Zero 2 W", Processor: "BCM2837", Memory: "512MB", Manufacturer: "Sony UK"},
+		Serial:     "00000000a1b2c3d4",
+		SoC:        "BCM2837", // not the kernel's BCM2835
+		CPU:        "Cortex-A53",
+		Cores:      4,
+		MemTotal:   439592 * 1024,
+		Kernel:     "6.6.51+rpt-rpi-v8",
+		OS:         "Debian GNU/Linux 12 (bookworm)",
+		BootDevice: "mmcblk0p2 (SD card)",
+		USB: []USBDevice{{
+			Bus:          "1-1",
+			VendorID:     "0bda",
+			ProductID:    "8153",
+			Manufacturer: "Realtek",
+			Product:      "USB 10/100/1000 LAN",
+			Speed:        "480",
+		}},
+	}
+	if !reflect.DeepEqual(hw, want) {
+		t.Errorf("ReadHardware() = %+v\nwant %+v", hw, want)
+	}
+
+	text, err := New(root).GetHardwareInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"Revision: 902120 (Pi Zero 2 W rev 1.0, made by Sony UK, 512MB)",
+		"CPU: 4 × Cortex-A53",
+		"Memory: 429 MB usable",
+		"• Realtek USB 10/100/1000 LAN [0bda:8153] 480 Mbit/s",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("GetHardwareInfo() is missing %q:\n%s", line, text)
+		}
+	}
+}
+
+func TestReadHardwareEmptyRoot(t *testing.T) {
+	root := t.TempDir()
+	if hw := ReadHardware(root); !reflect.DeepEqual(hw, &Hardware{}) {
+		t.Errorf("ReadHardware() of an empty root = %+v", hw)
+	}
+	if _, err := New(root).GetHardwareInfo(); err == nil {
+		t.Error("GetHardwareInfo() of an empty root succeeded")
+	}
+}
+
+func TestBootDevice(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		link  string // by-uuid symlink target, if any
+		want  string
+	}{
+		{
+			name:  "mounted partition",
+			files: map[string]string{"proc/mounts": "/dev/mmcblk0p2 / ext4 rw 0 0\n", "sys/block/mmcblk0/device/type": "MMC\n"},
+			want:  "mmcblk0p2 (eMMC)",
+		},
+		{
+			name:  "nvme",
+			files: map[string]string{"proc/mounts": "/dev/nvme0n1p2 / ext4 rw 0 0\n"},
+			want:  "nvme0n1p2 (NVMe)",
+		},
+		{
+			name:  "nfs root",
+			files: map[string]string{"proc/mounts": "10.0.0.1:/srv/pi / nfs rw 0 0\n"},
+			want:  "10.0.0.1:/srv/pi (network)",
+		},
+		{
+			name:  "unresolved uuid",
+			files: map[string]string{"proc/mounts": "/dev/root / ext4 rw 0 0\n", "proc/cmdline": "root=UUID=1234-abcd\n"},
+			want:  "UUID=1234-abcd",
+		},
+		{
+			name:  "uuid of a sata disk",
+			files: map[string]string{"proc/mounts": "/dev/root / ext4 rw 0 0\n", "proc/cmdline": "root=UUID=1234-abcd\n"},
+			link:  "../../sda2",
+			want:  "sda2 (SCSI/SATA)",
+		},
+		{name: "nothing", want: ""},
+	}
+
+	for _, tt := range tests {
+		root := t.TempDir()
+		writeFixture(t, root, tt.files)
+		if tt.link != "" {
+			symlink(t, tt.link, filepath.Join(root, "dev/disk/by-uuid/1234-abcd"))
+		}
+		if got := bootDevice(root); got != tt.want {
+			t.Errorf("%s: bootDevice() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
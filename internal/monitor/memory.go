@@ -0,0 +1,183 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// Pressure is one line of a Linux pressure-stall information file: the
+// share of time (in percent) some or all tasks were stalled, averaged over
+// 10, 60 and 300 seconds.
+type Pressure struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+}
+
+// MemoryPressure holds /proc/pressure/memory.
+type MemoryPressure struct {
+	Some Pressure
+	Full Pressure
+}
+
+// ZramDevice describes one compressed RAM block device.
+type ZramDevice struct {
+	Name          string
+	DiskSize      uint64 // configured capacity
+	OrigDataSize  uint64 // uncompressed size of the stored data
+	ComprDataSize uint64 // compressed size of the stored data
+	MemUsedTotal  uint64 // memory used, including allocator overhead
+}
+
+// CompressionRatio returns how many bytes of data each byte of memory holds.
+func (z ZramDevice) CompressionRatio() float64 {
+	if z.ComprDataSize == 0 {
+		return 0
+	}
+	return float64(z.OrigDataSize) / float64(z.ComprDataSize)
+}
+
+// ParsePressure parses the contents of a /proc/pressure file, e.g.
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func ParsePressure(data string) (*MemoryPressure, error) {
+	psi := &MemoryPressure{}
+	found := false
+
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var p *Pressure
+		switch fields[0] {
+		case "some":
+			p = &psi.Some
+		case "full":
+			p = &psi.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pressure value %q", field)
+			}
+			switch key {
+			case "avg10":
+				p.Avg10 = v
+			case "avg60":
+				p.Avg60 = v
+			case "avg300":
+				p.Avg300 = v
+			}
+		}
+		found = true
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no pressure data found")
+	}
+	return psi, nil
+}
+
+// ReadMemoryPressure reads /proc/pressure/memory under root. It fails on
+// kernels built without PSI.
+func ReadMemoryPressure(root string) (*MemoryPressure, error) {
+	data, err := os.ReadFile(filepath.Join(root, "proc/pressure/memory"))
+	if err != nil {
+		return nil, fmt.Errorf("memory pressure not available: %w", err)
+	}
+	return ParsePressure(string(data))
+}
+
+// ReadZram lists the zram devices under root with their usage, read from
+// /sys/block/zram*/mm_stat.
+func ReadZram(root string) []ZramDevice {
+	dirs, _ := filepath.Glob(filepath.Join(root, "sys/block/zram*"))
+
+	var devices []ZramDevice
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "mm_stat"))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) < 3 {
+			continue
+		}
+
+		device := ZramDevice{Name: filepath.Base(dir)}
+		device.OrigDataSize, _ = strconv.ParseUint(fields[0], 10, 64)
+		device.ComprDataSize, _ = strconv.ParseUint(fields[1], 10, 64)
+		device.MemUsedTotal, _ = strconv.ParseUint(fields[2], 10, 64)
+		if size, err := os.ReadFile(filepath.Join(dir, "disksize")); err == nil {
+			device.DiskSize, _ = strconv.ParseUint(strings.TrimSpace(string(size)), 10, 64)
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+func megabytes(b uint64) float64 {
+	return float64(b) / 1024 / 1024
+}
+
+// GetMemoryDetails reports RAM, swap, zram and memory pressure.
+func (m *Monitor) GetMemoryDetails() (string, error) {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return "", fmt.Errorf("error getting memory usage: %w", err)
+	}
+
+	var result strings.Builder
+	result.WriteString("Memory:\n")
+	result.WriteString(fmt.Sprintf("Total: %.0f MB\n", megabytes(vm.Total)))
+	result.WriteString(fmt.Sprintf("Used: %.0f MB (%.1f%%)\n", megabytes(vm.Used), vm.UsedPercent))
+	result.WriteString(fmt.Sprintf("Available: %.0f MB\n", megabytes(vm.Available)))
+	result.WriteString(fmt.Sprintf("Buffers/cache: %.0f MB\n", megabytes(vm.Buffers+vm.Cached)))
+	result.WriteString(fmt.Sprintf("Free: %.0f MB\n", megabytes(vm.Free)))
+
+	if swap, err := mem.SwapMemory(); err == nil {
+		if swap.Total == 0 {
+			result.WriteString("\nSwap: none\n")
+		} else {
+			result.WriteString(fmt.Sprintf("\nSwap: %.0f MB / %.0f MB (%.1f%%)\n",
+				megabytes(swap.Used), megabytes(swap.Total), swap.UsedPercent))
+		}
+	}
+
+	for _, z := range ReadZram(m.root) {
+		if z.DiskSize == 0 {
+			continue // present but not set up
+		}
+		result.WriteString(fmt.Sprintf("%s: %.1f MB stored in %.1f MB of %.0f MB",
+			z.Name, megabytes(z.OrigDataSize), megabytes(z.MemUsedTotal), megabytes(z.DiskSize)))
+		if ratio := z.CompressionRatio(); ratio > 0 {
+			result.WriteString(fmt.Sprintf(" (ratio %.2f)", ratio))
+		}
+		result.WriteString("\n")
+	}
+
+	if psi, err := ReadMemoryPressure(m.root); err == nil {
+		result.WriteString("\nPressure (avg 10s/60s/300s):\n")
+		result.WriteString(fmt.Sprintf("some: %.2f%% %.2f%% %.2f%%\n", psi.Some.Avg10, psi.Some.Avg60, psi.Some.Avg300))
+		result.WriteString(fmt.Sprintf("full: %.2f%% %.2f%% %.2f%%\n", psi.Full.Avg10, psi.Full.Avg60, psi.Full.Avg300))
+	} else {
+		result.WriteString("\nPressure: not available on this kernel\n")
+	}
+
+	return result.String(), nil
+}
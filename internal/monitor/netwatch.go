@@ -0,0 +1,226 @@
+package monitor
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// IPv6 address flags from /proc/net/if_inet6
+const (
+	ifaTemporary = 0x01 // privacy address, rotated by the kernel
+	ifaTentative = 0x40 // still in duplicate address detection
+)
+
+// ParseIfInet6 parses the contents of /proc/net/if_inet6 into addresses
+// (with prefix length) per interface. Temporary and tentative addresses are
+// left out: they come and go on their own and are not worth a message.
+func ParseIfInet6(data string) map[string][]string {
+	addrs := make(map[string][]string)
+
+	for _, line := range strings.Split(data, "\n") {
+		// address ifindex prefixlen scope flags name
+		fields := strings.Fields(line)
+		if len(fields) < 6 || len(fields[0]) != 32 {
+			continue
+		}
+		prefix, err1 := strconv.ParseUint(fields[2], 16, 8)
+		flags, err2 := strconv.ParseUint(fields[4], 16, 32)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if flags&(ifaTemporary|ifaTentative) != 0 {
+			continue
+		}
+
+		ip := make(net.IP, net.IPv6len)
+		for i := range ip {
+			b, err := strconv.ParseUint(fields[0][2*i:2*i+2], 16, 8)
+			if err != nil {
+				ip = nil
+				break
+			}
+			ip[i] = byte(b)
+		}
+		if ip == nil || ip.IsLoopback() {
+			continue
+		}
+
+		addrs[fields[5]] = append(addrs[fields[5]], fmt.Sprintf("%s/%d", ip, prefix))
+	}
+	return addrs
+}
+
+// interfaceAddrs returns the addresses of every non-loopback interface.
+// IPv6 addresses come from /proc/net/if_inet6 under root when it is
+// readable, so temporary addresses can be told apart.
+func interfaceAddrs(root string) (map[string][]string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("error getting network interfaces: %w", err)
+	}
+
+	// The file is missing when IPv6 is disabled and otherwise lists at
+	// least the loopback address
+	var inet6 map[string][]string
+	if data := readFile(root, "proc/net/if_inet6"); data != "" {
+		inet6 = ParseIfInet6(data)
+	}
+
+	addrs := make(map[string][]string)
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		list, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range list {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || (ipnet.IP.To4() == nil && inet6 != nil) {
+				continue
+			}
+			addrs[iface.Name] = append(addrs[iface.Name], ipnet.String())
+		}
+		addrs[iface.Name] = append(addrs[iface.Name], inet6[iface.Name]...)
+	}
+	return addrs, nil
+}
+
+// AddressWatcher reports changes to interface addresses and default
+// routes, such as a new DHCP lease. Like PowerWatcher it is polled through
+// Events.
+type AddressWatcher struct {
+	mu      sync.Mutex
+	root    string
+	started bool
+	addrs   map[string][]string
+	routes  []string
+}
+
+func NewAddressWatcher(root string) *AddressWatcher {
+	return &AddressWatcher{root: root}
+}
+
+// Events compares the current addresses and default routes with those seen
+// on the previous call and describes what changed in one message. The
+// first call only records the starting point.
+func (w *AddressWatcher) Events() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	addrs, err := interfaceAddrs(w.root)
+	if err != nil {
+		return nil, err
+	}
+	var routes []string
+	for _, r := range DefaultRoutes(w.root) {
+		routes = append(routes, fmt.Sprintf("%s via %s", r.Gateway, r.Interface))
+	}
+	sort.Strings(routes)
+
+	previousAddrs, previousRoutes := w.addrs, w.routes
+	w.addrs, w.routes = addrs, routes
+	if !w.started {
+		w.started = true
+		return nil, nil
+	}
+
+	changes := DiffAddresses(previousAddrs, addrs)
+	removed, added := diffStrings(previousRoutes, routes)
+	if len(removed) == 1 && len(added) == 1 {
+		changes = append(changes, fmt.Sprintf("Default route changed: %s → %s", removed[0], added[0]))
+		removed, added = nil, nil
+	}
+	for _, r := range removed {
+		changes = append(changes, "Default route removed: "+r)
+	}
+	for _, r := range added {
+		changes = append(changes, "Default route added: "+r)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	title := "🌐 Network change"
+	if hostname, err := os.Hostname(); err == nil {
+		title += " on " + hostname
+	}
+	return []string{title + ":\n" + strings.Join(changes, "\n")}, nil
+}
+
+// DiffAddresses describes the differences between two sets of interface
+// addresses. When one address of a family is replaced by another on the
+// same interface it is reported as a change rather than a removal and an
+// addition.
+func DiffAddresses(before, after map[string][]string) []string {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, name := range sorted {
+		removed, added := diffStrings(before[name], after[name])
+		for _, family := range []string{"IPv4", "IPv6"} {
+			gone, appeared := filterFamily(removed, family), filterFamily(added, family)
+			if len(gone) == 1 && len(appeared) == 1 {
+				changes = append(changes, fmt.Sprintf("%s %s changed: %s → %s", name, family, gone[0], appeared[0]))
+				continue
+			}
+			for _, a := range gone {
+				changes = append(changes, fmt.Sprintf("%s %s removed: %s", name, family, a))
+			}
+			for _, a := range appeared {
+				changes = append(changes, fmt.Sprintf("%s %s added: %s", name, family, a))
+			}
+		}
+	}
+	return changes
+}
+
+// diffStrings returns the entries only in before and only in after.
+func diffStrings(before, after []string) (removed, added []string) {
+	inBefore := make(map[string]bool)
+	for _, s := range before {
+		inBefore[s] = true
+	}
+	inAfter := make(map[string]bool)
+	for _, s := range after {
+		inAfter[s] = true
+		if !inBefore[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range before {
+		if !inAfter[s] {
+			removed = append(removed, s)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	return removed, added
+}
+
+func filterFamily(addrs []string, family string) []string {
+	var result []string
+	for _, a := range addrs {
+		if strings.Contains(a, ":") == (family == "IPv6") {
+			result = append(result, a)
+		}
+	}
+	return result
+}
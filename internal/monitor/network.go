@@ -0,0 +1,320 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NetCounters are the cumulative counters of one interface from
+// /proc/net/dev.
+type NetCounters struct {
+	RxBytes, RxPackets, RxErrors, RxDropped uint64
+	TxBytes, TxPackets, TxErrors, TxDropped uint64
+}
+
+// Route is a default route.
+type Route struct {
+	Interface string
+	Gateway   net.IP
+}
+
+// WirelessInfo is one line of /proc/net/wireless.
+type WirelessInfo struct {
+	LinkQuality float64 // usually out of 70
+	SignalLevel float64 // dBm
+	NoiseLevel  float64 // dBm
+}
+
+type netSnapshot struct {
+	at       time.Time
+	counters map[string]NetCounters
+}
+
+// ParseNetDev parses the contents of /proc/net/dev.
+func ParseNetDev(data string) (map[string]NetCounters, error) {
+	counters := make(map[string]NetCounters)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue // header lines
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("unexpected /proc/net/dev line for %s", strings.TrimSpace(name))
+		}
+
+		values := make([]uint64, 16)
+		for i := range values {
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid counter for %s: %w", strings.TrimSpace(name), err)
+			}
+			values[i] = v
+		}
+
+		counters[strings.TrimSpace(name)] = NetCounters{
+			RxBytes: values[0], RxPackets: values[1], RxErrors: values[2], RxDropped: values[3],
+			TxBytes: values[8], TxPackets: values[9], TxErrors: values[10], TxDropped: values[11],
+		}
+	}
+	return counters, scanner.Err()
+}
+
+// ParseDefaultRoutes returns the default routes in the contents of
+// /proc/net/route (IPv4) and /proc/net/ipv6_route (IPv6). Either may be
+// empty.
+func ParseDefaultRoutes(route, ipv6Route string) []Route {
+	var routes []Route
+
+	for _, line := range strings.Split(route, "\n") {
+		fields := strings.Fields(line)
+		// Iface Destination Gateway Flags ... with RTF_GATEWAY (0x2) set
+		if len(fields) < 4 || fields[1] != "00000000" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&0x2 == 0 {
+			continue
+		}
+		gw, err := hex.DecodeString(fields[2])
+		if err != nil || len(gw) != 4 {
+			continue
+		}
+		// The kernel prints the address in host (little-endian) order
+		routes = append(routes, Route{
+			Interface: fields[0],
+			Gateway:   net.IPv4(gw[3], gw[2], gw[1], gw[0]),
+		})
+	}
+
+	for _, line := range strings.Split(ipv6Route, "\n") {
+		fields := strings.Fields(line)
+		// dest prefix src prefix nexthop metric refcnt use flags iface
+		if len(fields) < 10 || fields[1] != "00" || strings.Trim(fields[0], "0") != "" {
+			continue
+		}
+		if fields[9] == "lo" || strings.Trim(fields[4], "0") == "" {
+			continue
+		}
+		gw, err := hex.DecodeString(fields[4])
+		if err != nil || len(gw) != 16 {
+			continue
+		}
+		routes = append(routes, Route{Interface: fields[9], Gateway: net.IP(gw)})
+	}
+
+	return routes
+}
+
+// ParseResolvConf returns the nameservers listed in resolv.conf contents.
+func ParseResolvConf(data string) []string {
+	var servers []string
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// ParseWireless parses the contents of /proc/net/wireless.
+func ParseWireless(data string) map[string]WirelessInfo {
+	info := make(map[string]WirelessInfo)
+
+	for _, line := range strings.Split(data, "\n") {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 4 {
+			continue
+		}
+
+		// Values carry a trailing "." when they were updated since the
+		// last read
+		parse := func(s string) float64 {
+			v, _ := strconv.ParseFloat(strings.TrimSuffix(s, "."), 64)
+			return v
+		}
+		info[strings.TrimSpace(name)] = WirelessInfo{
+			LinkQuality: parse(fields[1]),
+			SignalLevel: parse(fields[2]),
+			NoiseLevel:  parse(fields[3]),
+		}
+	}
+	return info
+}
+
+func readFile(root, path string) string {
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DefaultRoutes reads the default IPv4 and IPv6 routes under root.
+func DefaultRoutes(root string) []Route {
+	return ParseDefaultRoutes(readFile(root, "proc/net/route"), readFile(root, "proc/net/ipv6_route"))
+}
+
+func (m *Monitor) readNetCounters() (map[string]NetCounters, error) {
+	data, err := os.ReadFile(filepath.Join(m.root, "proc/net/dev"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading network counters: %w", err)
+	}
+	return ParseNetDev(string(data))
+}
+
+func (m *Monitor) sampleNetwork() error {
+	counters, err := m.readNetCounters()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.netPrev = m.netLast
+	m.netLast = &netSnapshot{at: time.Now(), counters: counters}
+	return nil
+}
+
+// netRates returns the latest counters and, per interface, the receive and
+// transmit rates in bytes per second over the sampler's latest window (or
+// a fresh one-second window if the sampler is not running).
+func (m *Monitor) netRates() (map[string]NetCounters, map[string][2]float64, error) {
+	m.mu.Lock()
+	prev, last := m.netPrev, m.netLast
+	m.mu.Unlock()
+
+	if prev == nil || last == nil || time.Since(last.at) > 3*SampleInterval {
+		first, err := m.readNetCounters()
+		if err != nil {
+			return nil, nil, err
+		}
+		start := time.Now()
+		time.Sleep(time.Second)
+		second, err := m.readNetCounters()
+		if err != nil {
+			return nil, nil, err
+		}
+		prev = &netSnapshot{at: start, counters: first}
+		last = &netSnapshot{at: time.Now(), counters: second}
+	}
+
+	seconds := last.at.Sub(prev.at).Seconds()
+	rates := make(map[string][2]float64)
+	for name, b := range last.counters {
+		a, ok := prev.counters[name]
+		// Counters reset when an interface is recreated
+		if !ok || seconds <= 0 || b.RxBytes < a.RxBytes || b.TxBytes < a.TxBytes {
+			continue
+		}
+		rates[name] = [2]float64{
+			float64(b.RxBytes-a.RxBytes) / seconds,
+			float64(b.TxBytes-a.TxBytes) / seconds,
+		}
+	}
+	return last.counters, rates, nil
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 MB".
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func (m *Monitor) GetNetworkDetails() (string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return "", fmt.Errorf("error getting network interfaces: %w", err)
+	}
+
+	counters, rates, err := m.netRates()
+	if err != nil {
+		return "", err
+	}
+	wireless := ParseWireless(readFile(m.root, "proc/net/wireless"))
+
+	var result strings.Builder
+	result.WriteString("Network Details:\n")
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		state := "down"
+		if iface.Flags&net.FlagUp != 0 {
+			state = "up"
+		}
+		result.WriteString(fmt.Sprintf("\n%s (%s)\n", iface.Name, state))
+
+		if len(iface.HardwareAddr) > 0 {
+			result.WriteString(fmt.Sprintf("  MAC: %s\n", iface.HardwareAddr))
+		}
+
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				ipnet, ok := addr.(*net.IPNet)
+				if !ok {
+					continue
+				}
+				family := "IPv6"
+				if ipnet.IP.To4() != nil {
+					family = "IPv4"
+				}
+				result.WriteString(fmt.Sprintf("  %s: %s\n", family, ipnet))
+			}
+		}
+
+		if rate, ok := rates[iface.Name]; ok {
+			result.WriteString(fmt.Sprintf("  Rate: ↓ %s/s ↑ %s/s\n",
+				formatBytes(uint64(rate[0])), formatBytes(uint64(rate[1]))))
+		}
+		if c, ok := counters[iface.Name]; ok {
+			result.WriteString(fmt.Sprintf("  Total: ↓ %s ↑ %s (%d / %d packets)\n",
+				formatBytes(c.RxBytes), formatBytes(c.TxBytes), c.RxPackets, c.TxPackets))
+			result.WriteString(fmt.Sprintf("  Errors: rx %d tx %d, Drops: rx %d tx %d\n",
+				c.RxErrors, c.TxErrors, c.RxDropped, c.TxDropped))
+		}
+
+		if w, ok := wireless[iface.Name]; ok {
+			result.WriteString(fmt.Sprintf("  Wi-Fi: link quality %.0f/70, signal %.0f dBm\n",
+				w.LinkQuality, w.SignalLevel))
+		}
+	}
+
+	routes := DefaultRoutes(m.root)
+	if len(routes) > 0 {
+		result.WriteString("\nDefault gateway:\n")
+		for _, r := range routes {
+			result.WriteString(fmt.Sprintf("  %s via %s\n", r.Gateway, r.Interface))
+		}
+	}
+
+	if servers := ParseResolvConf(readFile(m.root, "etc/resolv.conf")); len(servers) > 0 {
+		result.WriteString(fmt.Sprintf("\nDNS: %s\n", strings.Join(servers, ", ")))
+	}
+
+	return result.String(), nil
+}
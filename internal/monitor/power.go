@@ -0,0 +1,258 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// throttledPath is the firmware node exposing the same bitmask as
+// "vcgencmd get_throttled", relative to the monitor's root.
+const throttledPath = "sys/devices/platform/soc/soc:firmware/get_throttled"
+
+// vcgencmdTimeout bounds a "vcgencmd get_throttled" call.
+const vcgencmdTimeout = 5 * time.Second
+
+// Retry delays after vcgencmd fails, e.g. while the firmware is still
+// coming up at boot. The delay doubles up to the maximum.
+const (
+	minVcgencmdRetry = time.Minute
+	maxVcgencmdRetry = 30 * time.Minute
+)
+
+// ErrNoThrottleState is returned by ReadThrottled on hosts with neither
+// the firmware node nor vcgencmd, which are not a Pi.
+var ErrNoThrottleState = errors.New("throttling state not available (not a Raspberry Pi?)")
+
+// ThrottleState is the Raspberry Pi firmware throttled bitmask. The low bits
+// describe the current state, the same bits shifted by 16 record whether the
+// condition has occurred since boot.
+type ThrottleState uint32
+
+type throttleFlag struct {
+	bit   uint
+	label string
+}
+
+var throttleFlags = []throttleFlag{
+	{0, "under-voltage"},
+	{1, "ARM frequency capped"},
+	{2, "throttled"},
+	{3, "soft temperature limit"},
+}
+
+// ParseThrottled parses the output of "vcgencmd get_throttled"
+// ("throttled=0x50005") or the contents of the get_throttled sysfs node
+// ("50005").
+func ParseThrottled(s string) (ThrottleState, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "throttled=")
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid throttled value %q", s)
+	}
+	return ThrottleState(value), nil
+}
+
+// Current lists the conditions active right now.
+func (t ThrottleState) Current() []string {
+	return t.labels(0)
+}
+
+// SinceBoot lists the conditions that have occurred since boot.
+func (t ThrottleState) SinceBoot() []string {
+	return t.labels(16)
+}
+
+// UnderVoltage reports whether the supply is under-voltage right now.
+func (t ThrottleState) UnderVoltage() bool {
+	return t&1 != 0
+}
+
+// Throttled reports whether the CPU is throttled or frequency capped right
+// now.
+func (t ThrottleState) Throttled() bool {
+	return t&0b110 != 0
+}
+
+func (t ThrottleState) labels(shift uint) []string {
+	var labels []string
+	for _, f := range throttleFlags {
+		if t&(1<<(f.bit+shift)) != 0 {
+			labels = append(labels, f.label)
+		}
+	}
+	return labels
+}
+
+// ReadThrottled reads the throttled bitmask from sysfs, falling back to
+// vcgencmd when the node is not available. Without either it returns
+// ErrNoThrottleState and does not look for vcgencmd again. After other
+// vcgencmd failures the last error is returned until the retry is due.
+func (m *Monitor) ReadThrottled(ctx context.Context) (ThrottleState, error) {
+	data, err := os.ReadFile(filepath.Join(m.root, throttledPath))
+	if err == nil {
+		return ParseThrottled(string(data))
+	}
+
+	m.mu.Lock()
+	noVcgencmd, retryAt, lastErr := m.noVcgencmd, m.vcgencmdRetryAt, m.vcgencmdErr
+	m.mu.Unlock()
+	if noVcgencmd {
+		return 0, fmt.Errorf("%w: %w", ErrNoThrottleState, err)
+	}
+	if time.Now().Before(retryAt) {
+		return 0, lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, vcgencmdTimeout)
+	defer cancel()
+	out, cmdErr := m.runner.Run(ctx, "vcgencmd", "get_throttled")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cmdErr == nil {
+		m.vcgencmdDelay, m.vcgencmdRetryAt, m.vcgencmdErr = 0, time.Time{}, nil
+		return ParseThrottled(string(out))
+	}
+	if errors.Is(err, fs.ErrNotExist) && errors.Is(cmdErr, exec.ErrNotFound) {
+		m.noVcgencmd = true
+		return 0, fmt.Errorf("%w: %w", ErrNoThrottleState, errors.Join(err, cmdErr))
+	}
+
+	if m.vcgencmdDelay == 0 {
+		m.vcgencmdDelay = minVcgencmdRetry
+	} else if m.vcgencmdDelay *= 2; m.vcgencmdDelay > maxVcgencmdRetry {
+		m.vcgencmdDelay = maxVcgencmdRetry
+	}
+	m.vcgencmdRetryAt = time.Now().Add(m.vcgencmdDelay)
+	m.vcgencmdErr = fmt.Errorf("error reading throttling state: %w", errors.Join(err, cmdErr))
+	return 0, m.vcgencmdErr
+}
+
+// GetPowerStatus reports under-voltage and throttling, now and since boot.
+func (m *Monitor) GetPowerStatus() (string, error) {
+	state, err := m.ReadThrottled(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Power Status (0x%x):\n", uint32(state)))
+
+	if current := state.Current(); len(current) > 0 {
+		result.WriteString("⚠️ Now: " + strings.Join(current, ", ") + "\n")
+	} else {
+		result.WriteString("✅ Now: no problems\n")
+	}
+
+	if sinceBoot := state.SinceBoot(); len(sinceBoot) > 0 {
+		result.WriteString("⚠️ Since boot: " + strings.Join(sinceBoot, ", ") + "\n")
+	} else {
+		result.WriteString("✅ Since boot: no problems\n")
+	}
+
+	// Bit 16 is under-voltage since boot
+	if state.UnderVoltage() || state&(1<<16) != 0 {
+		result.WriteString("\nUnder-voltage usually means a weak power supply or cable.")
+	}
+
+	return result.String(), nil
+}
+
+// PowerWatcher reports new under-voltage and throttling events. It is an
+// alert.EventSource.
+type PowerWatcher struct {
+	mu       sync.Mutex
+	monitor  *Monitor
+	cooldown time.Duration
+	started  bool
+	disabled bool // not a Pi
+	failing  bool // reading the state failed on the previous call
+	previous ThrottleState
+	lastSent map[string]time.Time
+}
+
+func NewPowerWatcher(monitor *Monitor, cooldown time.Duration) *PowerWatcher {
+	return &PowerWatcher{
+		monitor:  monitor,
+		cooldown: cooldown,
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// Events reads the current state and returns a message for every condition
+// that has started since the previous call. The first call reports what has
+// already happened since boot.
+func (w *PowerWatcher) Events() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.disabled {
+		return nil, nil
+	}
+
+	state, err := w.monitor.ReadThrottled(context.Background())
+	if errors.Is(err, ErrNoThrottleState) {
+		w.disabled = true
+		log.Printf("Power monitoring disabled: %v", err)
+		return nil, nil
+	}
+	if err != nil {
+		// Say so once per outage; ReadThrottled retries with backoff
+		if !w.failing {
+			w.failing = true
+			log.Printf("Power monitoring is failing, retrying in the background: %v", err)
+		}
+		return nil, nil
+	}
+	if w.failing {
+		w.failing = false
+		log.Printf("Power monitoring works again")
+	}
+
+	previous := w.previous
+	w.previous = state
+
+	if !w.started {
+		w.started = true
+		if sinceBoot := state.SinceBoot(); len(sinceBoot) > 0 {
+			return []string{"⚡ Power problems have occurred since boot: " + strings.Join(sinceBoot, ", ")}, nil
+		}
+		return nil, nil
+	}
+
+	// Bits that went from clear to set, current or sticky
+	started := state &^ previous
+	now := time.Now()
+
+	var events []string
+	for _, label := range started.Current() {
+		if last, ok := w.lastSent[label]; ok && now.Sub(last) < w.cooldown {
+			continue
+		}
+		w.lastSent[label] = now
+		events = append(events, "⚡ Power: "+label+" detected")
+	}
+	// A sticky bit can be set by an event too short to be seen as current
+	for _, label := range started.SinceBoot() {
+		if _, seen := w.lastSent[label]; seen {
+			continue
+		}
+		w.lastSent[label] = now
+		events = append(events, "⚡ Power: "+label+" occurred since the last check")
+	}
+
+	return events, nil
+}
@@ -0,0 +1,249 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseThrottled(t *testing.T) {
+	tests := []struct {
+		in           string
+		want         ThrottleState
+		current      []string
+		sinceBoot    []string
+		underVoltage bool
+		throttled    bool
+		wantErr      bool
+	}{
+		{in: "throttled=0x0\n", want: 0},
+		{in: "0", want: 0},
+		{
+			in:           "throttled=0x50005",
+			want:         0x50005,
+			current:      []string{"under-voltage", "throttled"},
+			sinceBoot:    []string{"under-voltage", "throttled"},
+			underVoltage: true,
+			throttled:    true,
+		},
+		{
+			in:        "50000\n",
+			want:      0x50000,
+			sinceBoot: []string{"under-voltage", "throttled"},
+		},
+		{
+			in:        "throttled=0X80008",
+			want:      0x80008,
+			current:   []string{"soft temperature limit"},
+			sinceBoot: []string{"soft temperature limit"},
+		},
+		{in: "0x2", want: 2, current: []string{"ARM frequency capped"}, throttled: true},
+		{in: "", wantErr: true},
+		{in: "throttled=zz", wantErr: true},
+		{in: "0x100000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseThrottled(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseThrottled(%q) = %#x, want an error", tt.in, uint32(got))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseThrottled(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseThrottled(%q) = %#x, want %#x", tt.in, uint32(got), uint32(tt.want))
+		}
+		if c := got.Current(); !reflect.DeepEqual(c, tt.current) {
+			t.Errorf("ParseThrottled(%q).Current() = %v, want %v", tt.in, c, tt.current)
+		}
+		if s := got.SinceBoot(); !reflect.DeepEqual(s, tt.sinceBoot) {
+			t.Errorf("ParseThrottled(%q).SinceBoot() = %v, want %v", tt.in, s, tt.sinceBoot)
+		}
+		if got.UnderVoltage() != tt.underVoltage || got.Throttled() != tt.throttled {
+			t.Errorf("ParseThrottled(%q): under-voltage %v, throttled %v; want %v, %v",
+				tt.in, got.UnderVoltage(), got.Throttled(), tt.underVoltage, tt.throttled)
+		}
+	}
+}
+
+// countingRunner returns canned output and counts its calls.
+type countingRunner struct {
+	out   string
+	err   error
+	calls int
+}
+
+func (r *countingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.calls++
+	return []byte(r.out), r.err
+}
+
+func TestReadThrottled(t *testing.T) {
+	t.Run("sysfs", func(t *testing.T) {
+		root := t.TempDir()
+		path := filepath.Join(root, throttledPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("50005\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		runner := &countingRunner{err: errors.New("should not run")}
+		m := New(root)
+		m.SetRunner(runner)
+		state, err := m.ReadThrottled(context.Background())
+		if err != nil || state != 0x50005 {
+			t.Fatalf("ReadThrottled() = %#x, %v; want 0x50005", uint32(state), err)
+		}
+		if runner.calls != 0 {
+			t.Errorf("vcgencmd ran %d times, want 0", runner.calls)
+		}
+	})
+
+	t.Run("vcgencmd", func(t *testing.T) {
+		runner := &countingRunner{out: "throttled=0x4\n"}
+		m := New(t.TempDir())
+		m.SetRunner(runner)
+		state, err := m.ReadThrottled(context.Background())
+		if err != nil || state != 0x4 {
+			t.Fatalf("ReadThrottled() = %#x, %v; want 0x4", uint32(state), err)
+		}
+	})
+
+	t.Run("not a Pi", func(t *testing.T) {
+		cmdErr := &exec.Error{Name: "vcgencmd", Err: exec.ErrNotFound}
+		runner := &countingRunner{err: cmdErr}
+		m := New(t.TempDir())
+		m.SetRunner(runner)
+
+		for i := 0; i < 3; i++ {
+			_, err := m.ReadThrottled(context.Background())
+			if err == nil {
+				t.Fatal("ReadThrottled() succeeded, want an error")
+			}
+			if !errors.Is(err, ErrNoThrottleState) {
+				t.Errorf("error %q is not ErrNoThrottleState", err)
+			}
+			if i == 0 && !errors.Is(err, exec.ErrNotFound) {
+				t.Errorf("error %q does not wrap the vcgencmd failure", err)
+			}
+		}
+		if runner.calls != 1 {
+			t.Errorf("vcgencmd ran %d times, want 1", runner.calls)
+		}
+	})
+
+	t.Run("vcgencmd fails", func(t *testing.T) {
+		// e.g. a timeout while the firmware is busy at boot
+		runner := &countingRunner{err: context.DeadlineExceeded}
+		m := New(t.TempDir())
+		m.SetRunner(runner)
+
+		for i := 0; i < 3; i++ {
+			_, err := m.ReadThrottled(context.Background())
+			if err == nil || errors.Is(err, ErrNoThrottleState) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("ReadThrottled() error = %v, want the vcgencmd failure", err)
+			}
+		}
+		if runner.calls != 1 {
+			t.Errorf("vcgencmd ran %d times before the retry was due, want 1", runner.calls)
+		}
+
+		// The delay doubles with every failure, up to the maximum
+		for i := 0; i < 10; i++ {
+			m.vcgencmdRetryAt = time.Time{}
+			m.ReadThrottled(context.Background())
+		}
+		if m.vcgencmdDelay != maxVcgencmdRetry {
+			t.Errorf("retry delay %v, want %v", m.vcgencmdDelay, maxVcgencmdRetry)
+		}
+
+		runner.err, runner.out = nil, "throttled=0x0"
+		m.vcgencmdRetryAt = time.Time{}
+		if state, err := m.ReadThrottled(context.Background()); err != nil || state != 0 {
+			t.Fatalf("ReadThrottled() after vcgencmd recovered = %#x, %v", uint32(state), err)
+		}
+		if m.vcgencmdDelay != 0 {
+			t.Errorf("retry delay %v after a successful read, want 0", m.vcgencmdDelay)
+		}
+	})
+}
+
+func TestPowerWatcher(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, throttledPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := NewPowerWatcher(New(root), time.Hour)
+	steps := []struct {
+		state string
+		want  int
+	}{
+		{"0", 0},
+		{"50005", 2}, // under-voltage and throttled now
+		{"50005", 0}, // still going, nothing new
+		{"50000", 0}, // cleared
+		{"50005", 0}, // back within the cooldown
+		{"70005", 1}, // capping seen only in the sticky bits
+	}
+	for i, step := range steps {
+		write(step.state)
+		events, err := w.Events()
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if len(events) != step.want {
+			t.Errorf("step %d (%s): got events %q, want %d", i, step.state, events, step.want)
+		}
+	}
+}
+
+func TestPowerWatcherRetries(t *testing.T) {
+	runner := &countingRunner{err: context.DeadlineExceeded}
+	m := New(t.TempDir())
+	m.SetRunner(runner)
+	w := NewPowerWatcher(m, time.Hour)
+
+	if events, err := w.Events(); err != nil || len(events) != 0 {
+		t.Fatalf("Events() while vcgencmd fails = %q, %v", events, err)
+	}
+	if w.disabled {
+		t.Fatal("one vcgencmd failure disabled power monitoring")
+	}
+
+	runner.err, runner.out = nil, "throttled=0x50000"
+	m.vcgencmdRetryAt = time.Time{}
+	events, err := w.Events()
+	if err != nil || len(events) != 1 || !strings.Contains(events[0], "since boot") {
+		t.Errorf("Events() after vcgencmd recovered = %q, %v", events, err)
+	}
+}
+
+func TestPowerWatcherNotAPi(t *testing.T) {
+	m := New(t.TempDir())
+	m.SetRunner(&countingRunner{err: &exec.Error{Name: "vcgencmd", Err: exec.ErrNotFound}})
+	w := NewPowerWatcher(m, time.Hour)
+
+	if events, err := w.Events(); err != nil || len(events) != 0 || !w.disabled {
+		t.Errorf("Events() = %q, %v; disabled %v, want disabled", events, err, w.disabled)
+	}
+}
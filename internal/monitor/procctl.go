@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"mypibot-go/internal/command"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// ErrProtectedProcess is returned for processes the bot refuses to signal
+// or renice: init, kernel threads, the bot itself and its parent.
+var ErrProtectedProcess = errors.New("this process is protected")
+
+// signals are the signals /kill accepts, by name without the SIG prefix.
+var signals = map[string]int{
+	"HUP": 1, "INT": 2, "QUIT": 3, "KILL": 9, "USR1": 10,
+	"USR2": 12, "TERM": 15, "CONT": 18, "STOP": 19,
+}
+
+// ParseSignal accepts a signal name with or without the SIG prefix, in any
+// case, or its number, and returns the canonical name, e.g. "TERM".
+func ParseSignal(s string) (string, error) {
+	name := strings.TrimPrefix(strings.ToUpper(s), "SIG")
+	if _, ok := signals[name]; ok {
+		return name, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		for name, num := range signals {
+			if num == n {
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported signal %q: use HUP, INT, QUIT, KILL, USR1, USR2, TERM, CONT or STOP", s)
+}
+
+// ProcessDetails identifies a process for confirmation prompts. CreateTime
+// (milliseconds since the epoch) tells a reused PID apart from the process
+// that was confirmed.
+type ProcessDetails struct {
+	PID        int32
+	PPID       int32
+	Name       string
+	User       string
+	Cmdline    string
+	Nice       int32
+	CreateTime int64
+}
+
+// Process looks up a running process.
+func (m *Monitor) Process(pid int32) (*ProcessDetails, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("no process with PID %d", pid)
+	}
+
+	details := &ProcessDetails{PID: pid}
+	if details.Name, err = p.Name(); err != nil {
+		return nil, fmt.Errorf("error reading process %d: %w", pid, err)
+	}
+	if details.CreateTime, err = p.CreateTime(); err != nil {
+		return nil, fmt.Errorf("error reading process %d: %w", pid, err)
+	}
+	details.PPID, _ = p.Ppid()
+	details.User, _ = p.Username()
+	details.Cmdline, _ = p.Cmdline()
+	// gopsutil passes on the getpriority syscall's 20 - nice
+	if priority, err := p.Nice(); err == nil {
+		details.Nice = 20 - priority
+	}
+	return details, nil
+}
+
+// CheckProtected returns ErrProtectedProcess if p must not be touched.
+func CheckProtected(p *ProcessDetails) error {
+	switch {
+	case p.PID == 1:
+		return fmt.Errorf("%w: PID 1 is init", ErrProtectedProcess)
+	case p.PID == 2 || p.PPID == 2:
+		return fmt.Errorf("%w: %s is a kernel thread", ErrProtectedProcess, p.Name)
+	case int(p.PID) == os.Getpid():
+		return fmt.Errorf("%w: it is the bot itself", ErrProtectedProcess)
+	case int(p.PID) == os.Getppid():
+		return fmt.Errorf("%w: it is the bot's parent", ErrProtectedProcess)
+	}
+	return nil
+}
+
+// SignalProcess sends a signal (a name from ParseSignal) to a process.
+func (m *Monitor) SignalProcess(pid int32, signal string) error {
+	_, err := command.Sudo(context.Background(), m.runner, "kill", "-s", signal, strconv.Itoa(int(pid)))
+	if err != nil {
+		return fmt.Errorf("error sending SIG%s to %d (make sure NOPASSWD is configured in sudoers): %w",
+			signal, pid, err)
+	}
+	return nil
+}
+
+// ReniceProcess sets a process's nice value, from -20 (highest priority)
+// to 19 (lowest).
+func (m *Monitor) ReniceProcess(pid int32, nice int) error {
+	if nice < -20 || nice > 19 {
+		return fmt.Errorf("nice value must be between -20 and 19")
+	}
+	_, err := command.Sudo(context.Background(), m.runner, "renice", "-n", strconv.Itoa(nice), "-p", strconv.Itoa(int(pid)))
+	if err != nil {
+		return fmt.Errorf("error renicing %d (make sure NOPASSWD is configured in sudoers): %w", pid, err)
+	}
+	return nil
+}
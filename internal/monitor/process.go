@@ -2,64 +2,240 @@ package monitor
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/shirou/gopsutil/v4/mem"
 	"github.com/shirou/gopsutil/v4/process"
 )
 
-func (m *Monitor) GetTopProcesses() (string, error) {
-	processes, err := process.Processes()
+// Sort orders for TopOptions.
+const (
+	SortCPU = "cpu"
+	SortMem = "mem"
+	SortIO  = "io"
+)
+
+// TopWindow is how long process CPU time and I/O are sampled over for
+// GetTopProcesses.
+const TopWindow = time.Second
+
+// maxCmdlineLength truncates long command lines in process listings.
+const maxCmdlineLength = 80
+
+// TopOptions selects and orders the processes GetTopProcesses shows.
+type TopOptions struct {
+	SortBy string // SortCPU (default), SortMem or SortIO
+	Limit  int    // default 5
+	Filter string // case-insensitive match on name or command line
+	Group  bool   // aggregate processes with the same name
+}
+
+// ProcessStats is a process, or a group of same-name processes, with its
+// usage over the sampling window.
+type ProcessStats struct {
+	PID        int32
+	Name       string
+	User       string
+	State      string
+	Threads    int32
+	Cmdline    string
+	RSS        uint64
+	MemPercent float64
+	CPUPercent float64 // of one core, so it can exceed 100 on multi-core machines
+	ReadRate   float64 // bytes per second
+	WriteRate  float64
+	Count      int // processes in the group
+}
+
+type processSample struct {
+	proc   *process.Process
+	cpu    float64 // user + system seconds
+	read   uint64
+	write  uint64
+	hasIO  bool
+	create int64
+}
+
+func sampleProcess(p *process.Process) (processSample, bool) {
+	times, err := p.Times()
 	if err != nil {
-		return "", fmt.Errorf("error getting processes: %w", err)
+		return processSample{}, false
 	}
+	s := processSample{proc: p, cpu: times.User + times.System}
+	// Create time tells a reused PID apart from the original process
+	s.create, _ = p.CreateTime()
+	if io, err := p.IOCounters(); err == nil {
+		s.read, s.write, s.hasIO = io.ReadBytes, io.WriteBytes, true
+	}
+	return s, true
+}
 
-	type ProcessInfo struct {
-		pid     int32
-		name    string
-		cpuPerc float64
-		memPerc float32
+// TopProcesses samples every process over TopWindow and returns them
+// ordered and filtered as requested.
+func (m *Monitor) TopProcesses(opts TopOptions) ([]ProcessStats, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting processes: %w", err)
+	}
+	memInfo, err := mem.VirtualMemory()
+	if err != nil {
+		return nil, fmt.Errorf("error getting memory usage: %w", err)
 	}
 
-	var processInfos []ProcessInfo
+	filter := strings.ToLower(opts.Filter)
+	first := make(map[int32]processSample)
 	for _, p := range processes {
-		name, err := p.Name()
-		if err != nil {
-			continue
+		if s, ok := sampleProcess(p); ok {
+			first[p.Pid] = s
 		}
+	}
 
-		cpuPerc, err := p.CPUPercent()
-		if err != nil {
-			continue
+	start := time.Now()
+	time.Sleep(TopWindow)
+	seconds := time.Since(start).Seconds()
+
+	var stats []ProcessStats
+	for _, a := range first {
+		b, ok := sampleProcess(a.proc)
+		if !ok || b.create != a.create {
+			continue // exited during the window
 		}
 
-		memPerc, err := p.MemoryPercent()
+		name, err := a.proc.Name()
 		if err != nil {
 			continue
 		}
+		cmdline, _ := a.proc.Cmdline()
+		if filter != "" &&
+			!strings.Contains(strings.ToLower(name), filter) &&
+			!strings.Contains(strings.ToLower(cmdline), filter) {
+			continue
+		}
+
+		s := ProcessStats{
+			PID:        a.proc.Pid,
+			Name:       name,
+			Cmdline:    cmdline,
+			CPUPercent: math.Max(0, (b.cpu-a.cpu)/seconds*100),
+			Count:      1,
+		}
+		if a.hasIO && b.hasIO && b.read >= a.read && b.write >= a.write {
+			s.ReadRate = float64(b.read-a.read) / seconds
+			s.WriteRate = float64(b.write-a.write) / seconds
+		}
+		if info, err := a.proc.MemoryInfo(); err == nil {
+			s.RSS = info.RSS
+			s.MemPercent = float64(info.RSS) / float64(memInfo.Total) * 100
+		}
+		s.User, _ = a.proc.Username()
+		if status, err := a.proc.Status(); err == nil && len(status) > 0 {
+			s.State = status[0]
+		}
+		s.Threads, _ = a.proc.NumThreads()
+
+		stats = append(stats, s)
+	}
 
-		processInfos = append(processInfos, ProcessInfo{
-			pid:     p.Pid,
-			name:    name,
-			cpuPerc: cpuPerc,
-			memPerc: memPerc,
-		})
+	if opts.Group {
+		stats = groupProcesses(stats)
 	}
 
-	sort.Slice(processInfos, func(i, j int) bool {
-		return processInfos[i].cpuPerc > processInfos[j].cpuPerc
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		switch opts.SortBy {
+		case SortMem:
+			return a.RSS > b.RSS
+		case SortIO:
+			return a.ReadRate+a.WriteRate > b.ReadRate+b.WriteRate
+		default:
+			return a.CPUPercent > b.CPUPercent
+		}
 	})
 
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats, nil
+}
+
+// groupProcesses sums the usage of processes sharing a name.
+func groupProcesses(stats []ProcessStats) []ProcessStats {
+	groups := make(map[string]*ProcessStats)
+	var order []string
+	for _, s := range stats {
+		g, ok := groups[s.Name]
+		if !ok {
+			copied := s
+			groups[s.Name] = &copied
+			order = append(order, s.Name)
+			continue
+		}
+		g.Count++
+		g.CPUPercent += s.CPUPercent
+		g.RSS += s.RSS
+		g.MemPercent += s.MemPercent
+		g.ReadRate += s.ReadRate
+		g.WriteRate += s.WriteRate
+		g.Threads += s.Threads
+		if g.User != s.User {
+			g.User = "various"
+		}
+	}
+
+	grouped := make([]ProcessStats, 0, len(order))
+	for _, name := range order {
+		grouped = append(grouped, *groups[name])
+	}
+	return grouped
+}
+
+func (m *Monitor) GetTopProcesses(opts TopOptions) (string, error) {
+	stats, err := m.TopProcesses(opts)
+	if err != nil {
+		return "", err
+	}
+
+	sortBy := opts.SortBy
+	if sortBy == "" {
+		sortBy = SortCPU
+	}
+
 	var result strings.Builder
-	result.WriteString("Top 5 Processes:\n")
-	for i := 0; i < len(processInfos) && i < 5; i++ {
-		result.WriteString(fmt.Sprintf("%d. %s (PID: %d)\n   CPU: %.1f%%, MEM: %.1f%%\n",
-			i+1,
-			processInfos[i].name,
-			processInfos[i].pid,
-			processInfos[i].cpuPerc,
-			processInfos[i].memPerc,
-		))
+	title := fmt.Sprintf("Top %d Processes by %s", len(stats), strings.ToUpper(sortBy))
+	if opts.Group {
+		title = fmt.Sprintf("Top %d Process Groups by %s", len(stats), strings.ToUpper(sortBy))
+	}
+	result.WriteString(fmt.Sprintf("%s (over %s):\n", title, TopWindow))
+	if opts.Filter != "" {
+		result.WriteString(fmt.Sprintf("Matching %q\n", opts.Filter))
+	}
+	if len(stats) == 0 {
+		result.WriteString("No matching processes.\n")
+	}
+
+	for i, s := range stats {
+		if opts.Group {
+			result.WriteString(fmt.Sprintf("\n%d. %s ×%d (%s, %d threads)\n", i+1, s.Name, s.Count, s.User, s.Threads))
+		} else {
+			result.WriteString(fmt.Sprintf("\n%d. %s (PID: %d, %s, %s, %d threads)\n",
+				i+1, s.Name, s.PID, s.User, s.State, s.Threads))
+		}
+		result.WriteString(fmt.Sprintf("   CPU: %.1f%%, RSS: %s (%.1f%%), IO: r %s/s w %s/s\n",
+			s.CPUPercent, formatBytes(s.RSS), s.MemPercent, formatBytes(uint64(s.ReadRate)), formatBytes(uint64(s.WriteRate))))
+		if !opts.Group && s.Cmdline != "" {
+			cmdline := s.Cmdline
+			if runes := []rune(cmdline); len(runes) > maxCmdlineLength {
+				cmdline = string(runes[:maxCmdlineLength]) + "…"
+			}
+			result.WriteString(fmt.Sprintf("   %s\n", cmdline))
+		}
 	}
 
 	return result.String(), nil
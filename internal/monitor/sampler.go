@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SampleInterval is how often the background sampler reads counters. Rates
+// and usage percentages are computed over this window.
+const SampleInterval = 5 * time.Second
+
+// Run samples counters every SampleInterval until ctx is cancelled, so that
+// usage figures always cover a recent, fixed window instead of whatever time
+// passed since the previous command.
+func (m *Monitor) Run(ctx context.Context) {
+	m.sample()
+
+	ticker := time.NewTicker(SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sample()
+		}
+	}
+}
+
+func (m *Monitor) sample() {
+	if err := m.sampleCPU(); err != nil {
+		log.Printf("Error sampling CPU times: %v", err)
+	}
+	if err := m.sampleNetwork(); err != nil {
+		log.Printf("Error sampling network counters: %v", err)
+	}
+	if err := m.sampleDisk(); err != nil {
+		log.Printf("Error sampling disk counters: %v", err)
+	}
+}
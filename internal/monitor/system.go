@@ -1,29 +1,98 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
-	"net"
-	"os/exec"
-	"strings"
+	"sync"
 	"time"
 
-	"github.com/shirou/gopsutil/v4/cpu"
+	"mypibot-go/internal/command"
+
 	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
 	"github.com/shirou/gopsutil/v4/mem"
-	"github.com/shirou/gopsutil/v4/sensors"
 )
 
-type Monitor struct{}
+// Metric names reported by Metrics. These are the names alert rules use.
+const (
+	MetricCPUPercent  = "cpu_percent"
+	MetricRAMPercent  = "ram_percent"
+	MetricDiskPercent = "disk_percent"
+	MetricTemperature = "temperature"
+	MetricLoad1       = "load1"
+
+	// Share of the last 60s in which some task stalled waiting for memory
+	MetricMemPressure = "mem_pressure"
+
+	// Raspberry Pi only: 1 while the condition is active, 0 otherwise
+	MetricUnderVoltage = "under_voltage"
+	MetricThrottled    = "throttled"
+)
+
+// MetricNames lists every metric Metrics can report.
+var MetricNames = []string{
+	MetricCPUPercent,
+	MetricRAMPercent,
+	MetricDiskPercent,
+	MetricTemperature,
+	MetricLoad1,
+	MetricMemPressure,
+	MetricUnderVoltage,
+	MetricThrottled,
+}
+
+type Monitor struct {
+	// root is where /proc, /sys and friends are read from. It is "/" on the
+	// Pi itself and can point elsewhere, e.g. at a host mount in a container.
+	root string
+
+	mu          sync.Mutex
+	tempHistory []tempSample // recent CPU temperatures, oldest first
+	cpuPrev     *cpuSnapshot // the two latest sampler readings
+	cpuLast     *cpuSnapshot
+	netPrev     *netSnapshot
+	netLast     *netSnapshot
+	diskPrev    *diskSnapshot
+	diskLast    *diskSnapshot
+
+	// runner runs reboot, kill, renice and vcgencmd
+	runner command.Runner
+
+	// vcgencmd state for ReadThrottled
+	noVcgencmd      bool // neither the firmware node nor vcgencmd exists
+	vcgencmdErr     error
+	vcgencmdDelay   time.Duration // zero while vcgencmd works
+	vcgencmdRetryAt time.Time
+}
+
+func New(root string) *Monitor {
+	if root == "" {
+		root = "/"
+	}
+	return &Monitor{root: root, runner: command.Exec{}}
+}
+
+// SetRunner replaces how external commands are run, e.g. with canned
+// output.
+func (m *Monitor) SetRunner(r command.Runner) {
+	m.runner = r
+}
 
-func New() *Monitor {
-	return &Monitor{}
+// KnownMetric reports whether name is one of MetricNames.
+func (m *Monitor) KnownMetric(name string) bool {
+	for _, known := range MetricNames {
+		if name == known {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *Monitor) GetSystemStats() (string, error) {
-	cpuPercent, err := cpu.Percent(0, false)
+	cpuStats, err := m.formatCPUStats()
 	if err != nil {
-		return "", fmt.Errorf("error getting CPU usage: %w", err)
+		return "", err
 	}
 
 	memInfo, err := mem.VirtualMemory()
@@ -31,27 +100,57 @@ func (m *Monitor) GetSystemStats() (string, error) {
 		return "", fmt.Errorf("error getting memory usage: %w", err)
 	}
 
-	return fmt.Sprintf("CPU Usage: %.1f%%\nRAM Usage: %d MB / %d MB",
-		cpuPercent[0],
+	return fmt.Sprintf("%sRAM Usage: %d MB / %d MB",
+		cpuStats,
 		memInfo.Used/1024/1024,
 		memInfo.Total/1024/1024), nil
 }
 
-func (m *Monitor) GetTemperature() (string, error) {
-	temps, err := sensors.SensorsTemperatures()
+// Metrics samples every metric in MetricNames. Metrics that cannot be read
+// on this machine are left out of the map rather than reported as zero.
+// CPU usage covers the background sampler's latest window.
+func (m *Monitor) Metrics() (map[string]float64, error) {
+	metrics := make(map[string]float64)
+
+	if stats, err := m.CPUStats(); err == nil {
+		metrics[MetricCPUPercent] = stats.Total
+	}
+
+	if avg, err := load.Avg(); err == nil {
+		metrics[MetricLoad1] = avg.Load1
+	}
+
+	memInfo, err := mem.VirtualMemory()
 	if err != nil {
-		return "", fmt.Errorf("error getting temperature: %w", err)
+		return nil, fmt.Errorf("error getting memory usage: %w", err)
 	}
+	metrics[MetricRAMPercent] = memInfo.UsedPercent
 
-	var cpuTemp float64
-	for _, temp := range temps {
-		if strings.Contains(strings.ToLower(temp.SensorKey), "cpu") {
-			cpuTemp = temp.Temperature
-			break
-		}
+	if psi, err := ReadMemoryPressure(m.root); err == nil {
+		metrics[MetricMemPressure] = psi.Some.Avg60
+	}
+
+	if usage, err := disk.Usage("/"); err == nil {
+		metrics[MetricDiskPercent] = usage.UsedPercent
+	}
+
+	if temp, err := m.cpuTemperature(); err == nil {
+		metrics[MetricTemperature] = temp
 	}
 
-	return fmt.Sprintf("CPU Temperature: %.1f°C", cpuTemp), nil
+	if state, err := m.ReadThrottled(context.Background()); err == nil {
+		metrics[MetricUnderVoltage] = boolMetric(state.UnderVoltage())
+		metrics[MetricThrottled] = boolMetric(state.Throttled())
+	}
+
+	return metrics, nil
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 func (m *Monitor) GetUptime() (string, error) {
@@ -69,61 +168,9 @@ func (m *Monitor) GetUptime() (string, error) {
 		days, hours, minutes), nil
 }
 
-func (m *Monitor) GetDiskUsage() (string, error) {
-	partitions, err := disk.Partitions(false)
-	if err != nil {
-		return "", fmt.Errorf("error getting disk partitions: %w", err)
-	}
-
-	var result strings.Builder
-	result.WriteString("Disk Usage:\n")
-
-	for _, partition := range partitions {
-		usage, err := disk.Usage(partition.Mountpoint)
-		if err != nil {
-			continue
-		}
-		result.WriteString(fmt.Sprintf("%s:\n  Used: %.1f GB / %.1f GB (%.1f%%)\n",
-			partition.Mountpoint,
-			float64(usage.Used)/1024/1024/1024,
-			float64(usage.Total)/1024/1024/1024,
-			usage.UsedPercent,
-		))
-	}
-
-	return result.String(), nil
-}
-
-func (m *Monitor) GetNetworkDetails() (string, error) {
-	interfaces, err := net.Interfaces()
-	if err != nil {
-		return "", fmt.Errorf("error getting network interfaces: %w", err)
-	}
-
-	var result strings.Builder
-	result.WriteString("Network Details:\n")
-
-	for _, iface := range interfaces {
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
-		}
-
-		for _, addr := range addrs {
-			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
-				if ipnet.IP.To4() != nil {
-					result.WriteString(fmt.Sprintf("%s: %s\n", iface.Name, ipnet.IP.String()))
-				}
-			}
-		}
-	}
-
-	return result.String(), nil
-}
-
 func (m *Monitor) RebootSystem() (string, error) {
 	// Using -n flag with sudo to avoid password prompt
-	err := exec.Command("sudo", "-n", "reboot").Run()
+	_, err := command.Sudo(context.Background(), m.runner, "reboot")
 	if err != nil {
 		return "", fmt.Errorf("error rebooting system (make sure NOPASSWD is configured in sudoers): %w", err)
 	}
@@ -0,0 +1,205 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/sensors"
+)
+
+// ErrNoTemperatureSensor is returned when neither hwmon nor thermal zones
+// expose a temperature.
+var ErrNoTemperatureSensor = errors.New("no temperature sensor available on this system")
+
+const (
+	// tempHistoryWindow is how long CPU temperature samples are kept for
+	// the trend shown by /temp.
+	tempHistoryWindow = 15 * time.Minute
+	// tempTrendAge is how far back the trend compares against.
+	tempTrendAge = 5 * time.Minute
+)
+
+// TempReading is one sensor's temperature in °C.
+type TempReading struct {
+	Name    string
+	Celsius float64
+}
+
+type tempSample struct {
+	at      time.Time
+	celsius float64
+}
+
+// Temperatures returns every temperature sensor. hwmon sensors are used when
+// reading the local machine; thermal zones under the monitor's root are the
+// fallback, and the only source when root points elsewhere.
+func (m *Monitor) Temperatures() ([]TempReading, error) {
+	var readings []TempReading
+
+	if m.root == "/" {
+		// gopsutil may return partial results alongside a warning error
+		temps, _ := sensors.SensorsTemperatures()
+		for _, t := range temps {
+			if t.Temperature > 0 {
+				readings = append(readings, TempReading{Name: t.SensorKey, Celsius: t.Temperature})
+			}
+		}
+	}
+
+	if len(readings) == 0 {
+		readings = readThermalZones(m.root)
+	}
+	if len(readings) == 0 {
+		return nil, ErrNoTemperatureSensor
+	}
+
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].Name < readings[j].Name
+	})
+	return readings, nil
+}
+
+// readThermalZones reads /sys/class/thermal/thermal_zone*/{type,temp}.
+// Zones that cannot be read are skipped.
+func readThermalZones(root string) []TempReading {
+	zones, _ := filepath.Glob(filepath.Join(root, "sys/class/thermal/thermal_zone*"))
+
+	var readings []TempReading
+	for _, zone := range zones {
+		data, err := os.ReadFile(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			continue
+		}
+
+		name := filepath.Base(zone)
+		if t, err := os.ReadFile(filepath.Join(zone, "type")); err == nil {
+			name = strings.TrimSpace(string(t))
+		}
+		readings = append(readings, TempReading{Name: name, Celsius: milli / 1000})
+	}
+	return readings
+}
+
+// cpuTemperature returns the reading that best represents the CPU: the
+// first sensor named like a CPU or SoC sensor, otherwise the hottest one.
+// Every successful call is recorded for the trend.
+func (m *Monitor) cpuTemperature() (float64, error) {
+	readings, err := m.Temperatures()
+	if err != nil {
+		return 0, err
+	}
+
+	cpuTemp := readings[0].Celsius
+	found := false
+	for _, r := range readings {
+		name := strings.ToLower(r.Name)
+		if strings.Contains(name, "cpu") || strings.Contains(name, "soc") {
+			cpuTemp = r.Celsius
+			found = true
+			break
+		}
+	}
+	if !found {
+		for _, r := range readings {
+			if r.Celsius > cpuTemp {
+				cpuTemp = r.Celsius
+			}
+		}
+	}
+
+	m.recordTemperature(cpuTemp, time.Now())
+	return cpuTemp, nil
+}
+
+func (m *Monitor) recordTemperature(celsius float64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tempHistory = append(m.tempHistory, tempSample{at: now, celsius: celsius})
+
+	// Drop samples older than the window
+	i := 0
+	for i < len(m.tempHistory) && now.Sub(m.tempHistory[i].at) > tempHistoryWindow {
+		i++
+	}
+	m.tempHistory = m.tempHistory[i:]
+}
+
+// temperatureTrend returns the CPU temperature about tempTrendAge ago, and
+// the time of that sample.
+func (m *Monitor) temperatureTrend(now time.Time) (tempSample, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Newest sample that is at least tempTrendAge old
+	for i := len(m.tempHistory) - 1; i >= 0; i-- {
+		if now.Sub(m.tempHistory[i].at) >= tempTrendAge {
+			return m.tempHistory[i], true
+		}
+	}
+	return tempSample{}, false
+}
+
+// GetTemperature reports every sensor in unit, "C" or "F", along with how
+// the CPU temperature changed over the last few minutes.
+func (m *Monitor) GetTemperature(unit string) (string, error) {
+	readings, err := m.Temperatures()
+	if errors.Is(err, ErrNoTemperatureSensor) {
+		return "🌡 No temperature sensor is available on this system.", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	past, hasTrend := m.temperatureTrend(now)
+	cpuTemp, err := m.cpuTemperature()
+	if err != nil {
+		return "", err
+	}
+
+	format := func(celsius float64) string {
+		if unit == "F" {
+			return fmt.Sprintf("%.1f°F", celsius*9/5+32)
+		}
+		return fmt.Sprintf("%.1f°C", celsius)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("CPU Temperature: %s\n", format(cpuTemp)))
+
+	if hasTrend {
+		delta := cpuTemp - past.celsius
+		if unit == "F" {
+			delta = delta * 9 / 5
+		}
+		arrow := "→"
+		switch {
+		case delta >= 0.5:
+			arrow = "↑"
+		case delta <= -0.5:
+			arrow = "↓"
+		}
+		result.WriteString(fmt.Sprintf("Trend: %s %+.1f°%s vs %d min ago\n",
+			arrow, delta, unit, int(now.Sub(past.at).Round(time.Minute).Minutes())))
+	}
+
+	if len(readings) > 1 {
+		result.WriteString("\nSensors:\n")
+		for _, r := range readings {
+			result.WriteString(fmt.Sprintf("%s: %s\n", r.Name, format(r.Celsius)))
+		}
+	}
+
+	return result.String(), nil
+}
@@ -0,0 +1,334 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mypibot-go/internal/command"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Ways a Watch can find its process.
+const (
+	MatchName    = "name"    // exact process name, e.g. "mosquitto"
+	MatchCmdline = "cmdline" // regular expression over the command line
+	MatchPidfile = "pidfile" // file holding the PID, e.g. /run/nginx.pid
+)
+
+// restartTimeout bounds how long a restart command may run.
+const restartTimeout = time.Minute
+
+// Watch is a process the Watchdog expects to be running.
+type Watch struct {
+	ID      int64
+	Name    string
+	Match   string
+	Pattern string
+
+	re *regexp.Regexp
+}
+
+// NewWatch validates a watch definition.
+func NewWatch(id int64, name, match, pattern string) (*Watch, error) {
+	w := &Watch{ID: id, Name: name, Match: match, Pattern: pattern}
+	if name == "" || pattern == "" {
+		return nil, fmt.Errorf("a watch needs a name and a pattern")
+	}
+
+	switch match {
+	case MatchName:
+	case MatchPidfile:
+		if !filepath.IsAbs(pattern) {
+			return nil, fmt.Errorf("pidfile must be an absolute path")
+		}
+	case MatchCmdline:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command line pattern: %w", err)
+		}
+		w.re = re
+	default:
+		return nil, fmt.Errorf("match must be name, cmdline or pidfile")
+	}
+	return w, nil
+}
+
+func (w *Watch) String() string {
+	return fmt.Sprintf("%s (%s %s)", w.Name, w.Match, w.Pattern)
+}
+
+// WatchStatus is the state of a watch as of the latest check.
+type WatchStatus struct {
+	Watch   *Watch
+	Checked bool
+	Running bool
+	PID     int32     // the oldest matching process
+	Count   int       // number of matching processes
+	Since   time.Time // when the process started or was found missing
+}
+
+type watchState struct {
+	WatchStatus
+	created     int64 // create time of PID, to detect restarts
+	lastRestart time.Time
+}
+
+type procEntry struct {
+	pid     int32
+	name    string
+	cmdline string
+	created int64
+}
+
+// Watchdog checks that watched processes keep running, reports when one
+// disappears, comes back or restarts, and runs its configured restart
+// command. It is an alert.EventSource.
+type Watchdog struct {
+	mu       sync.Mutex
+	root     string
+	restart  map[string][]string // by watch name
+	runner   command.Runner      // runs restart commands
+	cooldown time.Duration
+	watches  []*Watch
+	state    map[int64]*watchState
+	events   []string
+}
+
+// NewWatchdog creates a watchdog. restart maps watch names to the command
+// run when that process is found missing; commands are retried at most once
+// per cooldown while the process stays down.
+func NewWatchdog(root string, restart map[string]string, cooldown time.Duration, watches []*Watch) *Watchdog {
+	w := &Watchdog{
+		root:     root,
+		restart:  make(map[string][]string),
+		runner:   command.Exec{},
+		cooldown: cooldown,
+		watches:  watches,
+		state:    make(map[int64]*watchState),
+	}
+	for name, command := range restart {
+		if args := strings.Fields(command); len(args) > 0 {
+			w.restart[name] = args
+		}
+	}
+	return w
+}
+
+// SetRunner replaces how restart commands are run, e.g. with canned
+// output.
+func (w *Watchdog) SetRunner(r command.Runner) {
+	w.runner = r
+}
+
+// HasRestart reports whether a restart command is configured for name.
+func (w *Watchdog) HasRestart(name string) bool {
+	_, ok := w.restart[name]
+	return ok
+}
+
+func (w *Watchdog) Add(watch *Watch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watches = append(w.watches, watch)
+}
+
+// Remove stops watching the watch with the given ID.
+func (w *Watchdog) Remove(id int64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, watch := range w.watches {
+		if watch.ID == id {
+			w.watches = append(w.watches[:i], w.watches[i+1:]...)
+			delete(w.state, id)
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses returns every watch with the result of its latest check.
+func (w *Watchdog) Statuses() []WatchStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	statuses := make([]WatchStatus, 0, len(w.watches))
+	for _, watch := range w.watches {
+		status := WatchStatus{Watch: watch}
+		if s, ok := w.state[watch.ID]; ok {
+			status = s.WatchStatus
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// listProcesses reads the processes watches are matched against. Command
+// lines are only read when a watch needs them.
+func listProcesses(withCmdline bool) ([]procEntry, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting processes: %w", err)
+	}
+
+	self := int32(os.Getpid())
+	entries := make([]procEntry, 0, len(processes))
+	for _, p := range processes {
+		if p.Pid == self {
+			continue
+		}
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		created, _ := p.CreateTime()
+		entry := procEntry{pid: p.Pid, name: name, created: created}
+		if withCmdline {
+			entry.cmdline, _ = p.Cmdline()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// find returns the processes matching watch.
+func (w *Watchdog) find(watch *Watch, procs []procEntry) []procEntry {
+	var pidfilePID int64 = -1
+	if watch.Match == MatchPidfile {
+		data := readFile(w.root, watch.Pattern)
+		pid, err := strconv.ParseInt(strings.TrimSpace(data), 10, 32)
+		if err != nil {
+			return nil
+		}
+		pidfilePID = pid
+	}
+
+	var matches []procEntry
+	for _, p := range procs {
+		switch watch.Match {
+		case MatchName:
+			if p.name != watch.Pattern {
+				continue
+			}
+		case MatchCmdline:
+			if !watch.re.MatchString(p.cmdline) {
+				continue
+			}
+		case MatchPidfile:
+			if int64(p.pid) != pidfilePID {
+				continue
+			}
+		}
+		matches = append(matches, p)
+	}
+	return matches
+}
+
+// Events checks every watch and returns what changed since the previous
+// call, plus the outcome of any restart commands that have finished. A
+// watch found missing on its first check is reported; one found running
+// is not.
+func (w *Watchdog) Events() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.watches) == 0 {
+		return w.drain(), nil
+	}
+
+	withCmdline := false
+	for _, watch := range w.watches {
+		withCmdline = withCmdline || watch.Match == MatchCmdline
+	}
+	procs, err := listProcesses(withCmdline)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, watch := range w.watches {
+		matches := w.find(watch, procs)
+
+		s, ok := w.state[watch.ID]
+		if !ok {
+			s = &watchState{}
+			w.state[watch.ID] = s
+		}
+		wasChecked, wasRunning, previousPID, previousCreated, previousSince := s.Checked, s.Running, s.PID, s.created, s.Since
+
+		s.WatchStatus = WatchStatus{Watch: watch, Checked: true, Count: len(matches)}
+		if len(matches) > 0 {
+			oldest := matches[0]
+			for _, m := range matches[1:] {
+				if m.created < oldest.created {
+					oldest = m
+				}
+			}
+			s.Running = true
+			s.PID = oldest.pid
+			s.created = oldest.created
+			s.Since = time.UnixMilli(oldest.created)
+		} else {
+			s.Since = now
+			s.created = 0
+		}
+
+		switch {
+		case !s.Running && (!wasChecked || wasRunning):
+			w.events = append(w.events, fmt.Sprintf("💀 Watched process %s is not running", watch))
+			w.maybeRestart(watch, s, now)
+		case !s.Running:
+			// Still down: keep the original time and retry the restart
+			// command once the cooldown has passed
+			s.Since = previousSince
+			w.maybeRestart(watch, s, now)
+		case wasChecked && !wasRunning:
+			w.events = append(w.events, fmt.Sprintf("✅ Watched process %s is running again (PID: %d)", watch.Name, s.PID))
+		case wasChecked && previousCreated != s.created:
+			w.events = append(w.events, fmt.Sprintf("🔄 Watched process %s restarted (PID: %d → %d)", watch.Name, previousPID, s.PID))
+		}
+	}
+
+	return w.drain(), nil
+}
+
+func (w *Watchdog) drain() []string {
+	events := w.events
+	w.events = nil
+	return events
+}
+
+// maybeRestart starts the watch's restart command in the background, at
+// most once per cooldown. Its outcome is reported with the next events.
+func (w *Watchdog) maybeRestart(watch *Watch, s *watchState, now time.Time) {
+	args, ok := w.restart[watch.Name]
+	if !ok || (!s.lastRestart.IsZero() && now.Sub(s.lastRestart) < w.cooldown) {
+		return
+	}
+	s.lastRestart = now
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), restartTimeout)
+		defer cancel()
+
+		out, err := w.runner.Run(ctx, args[0], args[1:]...)
+		text := fmt.Sprintf("🔁 Restarted %s with: %s", watch.Name, strings.Join(args, " "))
+		if err != nil {
+			text = fmt.Sprintf("❌ Restart command for %s failed: %v", watch.Name, err)
+			if output := strings.TrimSpace(string(out)); output != "" {
+				text += "\n" + output
+			}
+		}
+
+		w.mu.Lock()
+		w.events = append(w.events, text)
+		w.mu.Unlock()
+	}()
+}
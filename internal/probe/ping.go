@@ -0,0 +1,55 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// errICMPUnavailable means the process may not open an unprivileged ICMP
+// socket, e.g. because net.ipv4.ping_group_range excludes its group.
+var errICMPUnavailable = errors.New("unprivileged ICMP not available")
+
+// Ping sends one ICMP echo request to host and waits for the reply. It uses
+// an unprivileged ICMP datagram socket where the kernel allows it, and the
+// system ping command otherwise.
+func Ping(ctx context.Context, host string) error {
+	err := pingICMP(ctx, host)
+	if !errors.Is(err, errICMPUnavailable) {
+		return err
+	}
+
+	timeout := 5 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	seconds := int(timeout.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	out, err := exec.CommandContext(ctx, "ping", "-c", "1", "-W", strconv.Itoa(seconds), host).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("no reply from %s", host)
+		}
+		return fmt.Errorf("ping %s failed: %v: %s", host, err, lastLine(out))
+	}
+	return nil
+}
+
+func lastLine(out []byte) string {
+	s := string(out)
+	for len(s) > 0 && s[len(s)-1] == '\n' {
+		s = s[:len(s)-1]
+	}
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '\n' {
+			return s[i+1:]
+		}
+	}
+	return s
+}
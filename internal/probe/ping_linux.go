@@ -0,0 +1,91 @@
+//go:build linux
+
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// pingICMP sends an echo request over an unprivileged ICMP datagram socket.
+// The kernel fills in the identifier and strips the IP header from replies.
+func pingICMP(ctx context.Context, host string) error {
+	addr, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	var dst net.IP
+	for _, a := range addr {
+		if ip4 := a.IP.To4(); ip4 != nil {
+			dst = ip4
+			break
+		}
+	}
+	if dst == nil {
+		return fmt.Errorf("%s has no IPv4 address", host)
+	}
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_ICMP)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errICMPUnavailable, err)
+	}
+	file := os.NewFile(uintptr(fd), "icmp")
+	conn, err := net.FilePacketConn(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errICMPUnavailable, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+
+	seq := uint16(time.Now().UnixNano())
+	request := make([]byte, 16)
+	request[0] = 8 // echo request
+	binary.BigEndian.PutUint16(request[6:], seq)
+	copy(request[8:], "pikuttan")
+	binary.BigEndian.PutUint16(request[2:], checksum(request))
+
+	if _, err := conn.WriteTo(request, &net.UDPAddr{IP: dst}); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(reply)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return fmt.Errorf("no reply from %s", host)
+			}
+			return err
+		}
+		// Echo reply from the target with our sequence number
+		addr, ok := from.(*net.UDPAddr)
+		if ok && addr.IP.Equal(dst) && n >= 8 && reply[0] == 0 && binary.BigEndian.Uint16(reply[6:]) == seq {
+			return nil
+		}
+	}
+}
+
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package probe
+
+import "context"
+
+func pingICMP(ctx context.Context, host string) error {
+	return errICMPUnavailable
+}
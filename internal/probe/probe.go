@@ -0,0 +1,171 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Probe types.
+const (
+	TypePing = "ping"
+	TypeTCP  = "tcp"
+	TypeHTTP = "http"
+)
+
+// Probe describes one reachability check.
+type Probe struct {
+	Name     string
+	Type     string
+	Target   string // host for ping, host:port for tcp, URL for http
+	Interval time.Duration
+	Timeout  time.Duration
+
+	// HTTP only. A zero ExpectStatus accepts any 2xx or 3xx response.
+	ExpectStatus int
+	ExpectBody   string
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Probe   string
+	At      time.Time
+	Up      bool
+	Latency time.Duration
+	Err     string
+}
+
+// maxBodyBytes bounds how much of an HTTP response is searched for
+// ExpectBody.
+const maxBodyBytes = 64 * 1024
+
+// ParseProbe parses a probe spec of the form
+// "name|type|target|interval[|status[|body]]", e.g. "router|ping|192.168.1.1|1m"
+// or "app|http|http://nas:8080/health|5m|200|ok".
+func ParseProbe(spec string, timeout time.Duration) (*Probe, error) {
+	fields := strings.Split(spec, "|")
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("invalid probe %q: expected name|type|target|interval", spec)
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	p := &Probe{
+		Name:    fields[0],
+		Type:    fields[1],
+		Target:  fields[2],
+		Timeout: timeout,
+	}
+	if p.Name == "" || p.Target == "" {
+		return nil, fmt.Errorf("invalid probe %q: name and target are required", spec)
+	}
+
+	interval, err := time.ParseDuration(fields[3])
+	if err != nil || interval < time.Second {
+		return nil, fmt.Errorf("invalid probe %q: interval must be at least 1s", spec)
+	}
+	p.Interval = interval
+
+	switch p.Type {
+	case TypePing:
+	case TypeTCP:
+		if _, _, err := net.SplitHostPort(p.Target); err != nil {
+			return nil, fmt.Errorf("invalid probe %q: tcp target must be host:port", spec)
+		}
+	case TypeHTTP:
+		if !strings.HasPrefix(p.Target, "http://") && !strings.HasPrefix(p.Target, "https://") {
+			return nil, fmt.Errorf("invalid probe %q: http target must be a URL", spec)
+		}
+		if len(fields) > 4 && fields[4] != "" {
+			p.ExpectStatus, err = strconv.Atoi(fields[4])
+			if err != nil {
+				return nil, fmt.Errorf("invalid probe %q: bad expected status", spec)
+			}
+		}
+		if len(fields) > 5 {
+			p.ExpectBody = strings.Join(fields[5:], "|")
+		}
+	default:
+		return nil, fmt.Errorf("invalid probe %q: type must be ping, tcp or http", spec)
+	}
+
+	return p, nil
+}
+
+// Check runs the probe once.
+func Check(ctx context.Context, p *Probe) Result {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch p.Type {
+	case TypePing:
+		err = Ping(ctx, p.Target)
+	case TypeTCP:
+		err = checkTCP(ctx, p.Target)
+	case TypeHTTP:
+		err = checkHTTP(ctx, p)
+	default:
+		err = fmt.Errorf("unknown probe type %q", p.Type)
+	}
+
+	result := Result{
+		Probe:   p.Name,
+		At:      start,
+		Up:      err == nil,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		result.Err = err.Error()
+	}
+	return result
+}
+
+func checkTCP(ctx context.Context, target string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func checkHTTP(ctx context.Context, p *Probe) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Target, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if p.ExpectStatus != 0 {
+		if resp.StatusCode != p.ExpectStatus {
+			return fmt.Errorf("status %d, expected %d", resp.StatusCode, p.ExpectStatus)
+		}
+	} else if resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	if p.ExpectBody != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+		if err != nil {
+			return fmt.Errorf("error reading body: %w", err)
+		}
+		if !strings.Contains(string(body), p.ExpectBody) {
+			return fmt.Errorf("body does not contain %q", p.ExpectBody)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseProbe(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Probe
+		wantErr bool
+	}{
+		{
+			spec: "router|ping|192.168.1.1|1m",
+			want: Probe{Name: "router", Type: TypePing, Target: "192.168.1.1", Interval: time.Minute},
+		},
+		{
+			spec: " nas | tcp | 192.168.1.10:445 | 2m ",
+			want: Probe{Name: "nas", Type: TypeTCP, Target: "192.168.1.10:445", Interval: 2 * time.Minute},
+		},
+		{
+			spec: "app|http|http://nas:8080/health|5m|200|ok|ready",
+			want: Probe{Name: "app", Type: TypeHTTP, Target: "http://nas:8080/health", Interval: 5 * time.Minute,
+				ExpectStatus: 200, ExpectBody: "ok|ready"},
+		},
+		{
+			spec: "app|http|https://example.com|30s||healthy",
+			want: Probe{Name: "app", Type: TypeHTTP, Target: "https://example.com", Interval: 30 * time.Second,
+				ExpectBody: "healthy"},
+		},
+		{spec: "router|ping|192.168.1.1", wantErr: true},
+		{spec: "|ping|192.168.1.1|1m", wantErr: true},
+		{spec: "router|ping|192.168.1.1|500ms", wantErr: true},
+		{spec: "nas|tcp|192.168.1.10|1m", wantErr: true},
+		{spec: "app|http|nas:8080|1m", wantErr: true},
+		{spec: "app|http|http://nas|1m|ok", wantErr: true},
+		{spec: "app|udp|nas:53|1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseProbe(tt.spec, 5*time.Second)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseProbe(%q) = %+v, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseProbe(%q): %v", tt.spec, err)
+			continue
+		}
+		tt.want.Timeout = 5 * time.Second
+		if *got != tt.want {
+			t.Errorf("ParseProbe(%q) = %+v, want %+v", tt.spec, *got, tt.want)
+		}
+	}
+}
+
+func TestCheckHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("status: healthy"))
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/big":
+			// The expected text lies past maxBodyBytes
+			w.Write([]byte(strings.Repeat("x", maxBodyBytes) + "healthy"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		body    string
+		timeout time.Duration
+		wantUp  bool
+		wantErr string
+	}{
+		{name: "ok", path: "/ok", wantUp: true},
+		{name: "redirect followed", path: "/redirect", wantUp: true},
+		{name: "expected status", path: "/created", status: 201, wantUp: true},
+		{name: "unexpected status", path: "/ok", status: 201, wantErr: "status 200, expected 201"},
+		{name: "not found", path: "/missing", wantErr: "status 404"},
+		{name: "expected body", path: "/ok", body: "healthy", wantUp: true},
+		{name: "missing body", path: "/ok", body: "ready", wantErr: `body does not contain "ready"`},
+		{name: "body past limit", path: "/big", body: "healthy", wantErr: "body does not contain"},
+		{name: "timeout", path: "/slow", timeout: 50 * time.Millisecond, wantErr: "deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			p := &Probe{
+				Name:         tt.name,
+				Type:         TypeHTTP,
+				Target:       server.URL + tt.path,
+				Timeout:      timeout,
+				ExpectStatus: tt.status,
+				ExpectBody:   tt.body,
+			}
+
+			result := Check(context.Background(), p)
+			if result.Up != tt.wantUp {
+				t.Fatalf("Up = %v (err %q), want %v", result.Up, result.Err, tt.wantUp)
+			}
+			if !strings.Contains(result.Err, tt.wantErr) {
+				t.Errorf("Err = %q, want it to contain %q", result.Err, tt.wantErr)
+			}
+			if result.Probe != tt.name || result.At.IsZero() {
+				t.Errorf("result not labelled: %+v", result)
+			}
+		})
+	}
+}
+
+func TestCheckTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+
+	p := &Probe{Name: "open", Type: TypeTCP, Target: addr, Timeout: time.Second}
+	if result := Check(context.Background(), p); !result.Up {
+		t.Errorf("open port reported down: %s", result.Err)
+	}
+
+	listener.Close()
+	p = &Probe{Name: "closed", Type: TypeTCP, Target: addr, Timeout: time.Second}
+	if result := Check(context.Background(), p); result.Up {
+		t.Error("closed port reported up")
+	}
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	results []bool
+}
+
+func (s *memoryStore) AddProbeResult(probe string, at time.Time, up bool, latency time.Duration, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, up)
+	return nil
+}
+
+func TestRunnerEvents(t *testing.T) {
+	p := &Probe{Name: "app", Type: TypeHTTP, Target: "http://app"}
+	store := &memoryStore{}
+	r := NewRunner([]*Probe{p}, store)
+
+	start := time.Now()
+	steps := []struct {
+		up   bool
+		want string
+	}{
+		{true, ""}, // starting up is not news
+		{true, ""},
+		{false, "🔴 Probe app (http http://app) is down: refused"},
+		{false, ""},
+		{true, "🟢 Probe app (http http://app) is back up after 2m0s"},
+	}
+	for i, step := range steps {
+		result := Result{Probe: p.Name, At: start.Add(time.Duration(i) * time.Minute), Up: step.up}
+		if !step.up {
+			result.Err = "refused"
+		}
+		r.record(p, result)
+
+		events, _ := r.Events()
+		switch {
+		case step.want == "" && len(events) > 0:
+			t.Errorf("step %d: unexpected events %q", i, events)
+		case step.want != "" && (len(events) != 1 || !strings.HasPrefix(events[0], step.want)):
+			t.Errorf("step %d: events %q, want %q", i, events, step.want)
+		}
+	}
+
+	if len(store.results) != len(steps) {
+		t.Errorf("stored %d results, want %d", len(store.results), len(steps))
+	}
+	if last, ok := r.Last("app"); !ok || !last.Up {
+		t.Errorf("Last() = %+v, %v", last, ok)
+	}
+}
+
+func TestRunnerReportsDownOnFirstCheck(t *testing.T) {
+	p := &Probe{Name: "nas", Type: TypeTCP, Target: "nas:445"}
+	r := NewRunner([]*Probe{p}, &memoryStore{})
+	r.record(p, Result{Probe: p.Name, At: time.Now(), Err: "timeout"})
+
+	if events, _ := r.Events(); len(events) != 1 {
+		t.Errorf("events %q, want one down event", events)
+	}
+}
@@ -0,0 +1,115 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Store records probe results.
+type Store interface {
+	AddProbeResult(probe string, at time.Time, up bool, latency time.Duration, errMsg string) error
+}
+
+// Runner checks each probe on its own schedule, records every result and
+// reports up/down changes as alert events.
+type Runner struct {
+	probes []*Probe
+	store  Store
+
+	mu        sync.Mutex
+	last      map[string]Result
+	downSince map[string]time.Time
+	events    []string
+}
+
+func NewRunner(probes []*Probe, store Store) *Runner {
+	return &Runner{
+		probes:    probes,
+		store:     store,
+		last:      make(map[string]Result),
+		downSince: make(map[string]time.Time),
+	}
+}
+
+// Probes returns the configured probes in configuration order.
+func (r *Runner) Probes() []*Probe {
+	return r.probes
+}
+
+// Last returns the most recent result of the named probe.
+func (r *Runner) Last(name string) (Result, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result, ok := r.last[name]
+	return result, ok
+}
+
+// Run checks every probe until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range r.probes {
+		wg.Add(1)
+		go func(p *Probe) {
+			defer wg.Done()
+			r.loop(ctx, p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, p *Probe) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.record(p, Check(ctx, p))
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Runner) record(p *Probe, result Result) {
+	if err := r.store.AddProbeResult(result.Probe, result.At, result.Up, result.Latency, result.Err); err != nil {
+		log.Printf("Error recording probe %s: %v", p.Name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, seen := r.last[p.Name]
+	r.last[p.Name] = result
+
+	switch {
+	case !result.Up && (!seen || prev.Up):
+		r.downSince[p.Name] = result.At
+		r.events = append(r.events, fmt.Sprintf("🔴 Probe %s (%s %s) is down: %s",
+			p.Name, p.Type, p.Target, result.Err))
+	case result.Up && seen && !prev.Up:
+		r.events = append(r.events, fmt.Sprintf("🟢 Probe %s (%s %s) is back up after %s (%d ms)",
+			p.Name, p.Type, p.Target, formatDowntime(result.At.Sub(r.downSince[p.Name])), result.Latency.Milliseconds()))
+	}
+}
+
+// Events returns the up/down changes seen since the last call. A probe
+// that is down on its first check is reported; one that starts up is not.
+func (r *Runner) Events() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events, nil
+}
+
+func formatDowntime(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return d.Round(time.Minute).String()
+}
@@ -246,7 +246,7 @@ func (m *Manager) reminderLoop(reminderID int64, timer *time.Timer, message stri
 			return
 		}
 
-		if reminder.Status != "active" {
+		if reminder == nil || reminder.Status != "active" {
 			log.Printf("Reminder %d is no longer active, stopping loop", reminderID)
 			delete(m.timers, reminderID)
 			m.Unlock()
@@ -255,30 +255,31 @@ func (m *Manager) reminderLoop(reminderID int64, timer *time.Timer, message stri
 
 		// Send notification
 		msg := tgbotapi.NewMessage(reminder.ChatID, fmt.Sprintf("🔔 Reminder: %s", message))
+		if settings, err := m.db.GetChatSettings(reminder.ChatID); err != nil {
+			log.Printf("Error getting settings for chat %d: %v", reminder.ChatID, err)
+		} else {
+			msg.DisableNotification = settings.SilentNotifications
+		}
+		status := "sent"
 		_, err = m.bot.Send(msg)
 		if err != nil {
 			log.Printf("Error sending reminder %d: %v", reminderID, err)
+			status = "failed"
 		}
 
-		// Update last triggered time in database
+		// Update last triggered time in database. These writes may be
+		// buffered, so the next trigger is computed from the interval
+		// rather than read back.
 		err = m.db.UpdateReminderTrigger(reminderID)
 		if err != nil {
 			log.Printf("Error updating reminder trigger %d: %v", reminderID, err)
 		}
-
-		updatedReminder, err := m.db.GetReminder(reminderID)
-		if err != nil {
-			log.Printf("Error getting reminder %d: %v", reminderID, err)
-			m.Unlock()
-			return
+		if err := m.db.AddReminderHistory(reminderID, status); err != nil {
+			log.Printf("Error recording reminder history %d: %v", reminderID, err)
 		}
 
 		// Reset timer for next interval
-		nextTrigger := updatedReminder.NextTrigger.Time
-		if nextTrigger.IsZero() {
-			nextTrigger = updatedReminder.CreatedAt.Add(time.Duration(updatedReminder.Interval) * time.Minute)
-		}
-		timer.Reset(time.Until(nextTrigger))
+		timer.Reset(time.Duration(reminder.Interval) * time.Minute)
 		m.Unlock()
 	}
 }
\ No newline at end of file
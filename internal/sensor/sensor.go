@@ -0,0 +1,220 @@
+// Package sensor accepts readings pushed by external sensors, such as ESP32
+// boards, and makes the latest ones available as alert metrics.
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricPrefix starts the metric name of every sensor reading. Readings are
+// stored and alerted on as "sensor.<sensor>.<metric>".
+const MetricPrefix = "sensor."
+
+// maxUnitLength bounds the unit of a reading, e.g. "°C" or "ppm".
+const maxUnitLength = 16
+
+// validName matches sensor and metric names. Dots are left out as they
+// separate the parts of the full metric name.
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Reading is one value reported by a sensor.
+type Reading struct {
+	Sensor string
+	Metric string // e.g. "temperature"
+	Value  float64
+	Unit   string
+	At     time.Time
+}
+
+// Name is the reading's full metric name, e.g. "sensor.kitchen.temperature".
+func (r *Reading) Name() string {
+	return MetricName(r.Sensor, r.Metric)
+}
+
+// Validate checks the reading's names, value and unit.
+func (r *Reading) Validate() error {
+	if !validName.MatchString(r.Sensor) {
+		return fmt.Errorf("invalid sensor name %q: use 1-64 letters, digits, _ or -", r.Sensor)
+	}
+	if !validName.MatchString(r.Metric) {
+		return fmt.Errorf("invalid metric name %q: use 1-64 letters, digits, _ or -", r.Metric)
+	}
+	if math.IsNaN(r.Value) || math.IsInf(r.Value, 0) {
+		return fmt.Errorf("invalid value for %s: must be a finite number", r.Name())
+	}
+	if len(r.Unit) > maxUnitLength {
+		return fmt.Errorf("invalid unit for %s: at most %d bytes", r.Name(), maxUnitLength)
+	}
+	return nil
+}
+
+// MetricName joins a sensor and metric into a full metric name.
+func MetricName(sensor, metric string) string {
+	return MetricPrefix + sensor + "." + metric
+}
+
+// ParseMetricName splits a full metric name into sensor and metric.
+func ParseMetricName(name string) (sensor, metric string, ok bool) {
+	rest, ok := strings.CutPrefix(name, MetricPrefix)
+	if !ok {
+		return "", "", false
+	}
+	sensor, metric, ok = strings.Cut(rest, ".")
+	if !ok || !validName.MatchString(sensor) || !validName.MatchString(metric) {
+		return "", "", false
+	}
+	return sensor, metric, true
+}
+
+// Store records sensor readings.
+type Store interface {
+	AddSensorReading(sensor, metric, unit string, value float64, at time.Time) error
+}
+
+// Registry keeps the latest reading of every sensor metric. It is the
+// alert engine's source for sensor metrics and reports sensors that have
+// gone silent.
+type Registry struct {
+	store      Store
+	staleAfter time.Duration
+
+	mu     sync.Mutex
+	latest map[string]Reading // by full metric name
+	silent map[string]bool    // sensors already reported as silent
+}
+
+// NewRegistry returns a registry that stores readings in store. A sensor
+// whose newest reading is older than staleAfter is silent: its metrics are
+// no longer reported to the alert engine.
+func NewRegistry(store Store, staleAfter time.Duration) *Registry {
+	return &Registry{
+		store:      store,
+		staleAfter: staleAfter,
+		latest:     make(map[string]Reading),
+		silent:     make(map[string]bool),
+	}
+}
+
+// Restore seeds the latest readings, e.g. from the database at startup.
+// Sensors that are already stale are not announced as silent again.
+func (r *Registry) Restore(readings []Reading) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, reading := range readings {
+		r.latest[reading.Name()] = reading
+	}
+	for sensor, at := range r.lastSeen() {
+		if now.Sub(at) > r.staleAfter {
+			r.silent[sensor] = true
+		}
+	}
+}
+
+// Record validates readings and stores them.
+func (r *Registry) Record(readings []Reading) error {
+	for i := range readings {
+		if err := readings[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, reading := range readings {
+		if err := r.store.AddSensorReading(reading.Sensor, reading.Name(), reading.Unit, reading.Value, reading.At); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, reading := range readings {
+		r.latest[reading.Name()] = reading
+	}
+	return nil
+}
+
+// Latest returns the newest reading of every sensor metric, sorted by
+// sensor and metric.
+func (r *Registry) Latest() []Reading {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	readings := make([]Reading, 0, len(r.latest))
+	for _, reading := range r.latest {
+		readings = append(readings, reading)
+	}
+	sort.Slice(readings, func(i, j int) bool {
+		if readings[i].Sensor != readings[j].Sensor {
+			return readings[i].Sensor < readings[j].Sensor
+		}
+		return readings[i].Metric < readings[j].Metric
+	})
+	return readings
+}
+
+// Stale reports whether a reading is too old to alert on.
+func (r *Registry) Stale(reading Reading) bool {
+	return time.Since(reading.At) > r.staleAfter
+}
+
+// Metrics returns the fresh readings by full metric name.
+func (r *Registry) Metrics() (map[string]float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics := make(map[string]float64)
+	for name, reading := range r.latest {
+		if time.Since(reading.At) <= r.staleAfter {
+			metrics[name] = reading.Value
+		}
+	}
+	return metrics, nil
+}
+
+// KnownMetric accepts any well-formed sensor metric name, so rules can be
+// set up before a sensor first reports.
+func (r *Registry) KnownMetric(name string) bool {
+	_, _, ok := ParseMetricName(name)
+	return ok
+}
+
+// Events reports sensors that stopped reporting and ones that came back.
+func (r *Registry) Events() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var events []string
+	for sensor, at := range r.lastSeen() {
+		stale := now.Sub(at) > r.staleAfter
+		switch {
+		case stale && !r.silent[sensor]:
+			r.silent[sensor] = true
+			events = append(events, fmt.Sprintf("📡 Sensor %s has not reported since %s", sensor, at.Local().Format("Jan 2 15:04")))
+		case !stale && r.silent[sensor]:
+			delete(r.silent, sensor)
+			events = append(events, fmt.Sprintf("✅ Sensor %s is reporting again", sensor))
+		}
+	}
+	sort.Strings(events)
+	return events, nil
+}
+
+// lastSeen returns when each sensor last reported any metric. The caller
+// holds r.mu.
+func (r *Registry) lastSeen() map[string]time.Time {
+	seen := make(map[string]time.Time)
+	for _, reading := range r.latest {
+		if reading.At.After(seen[reading.Sensor]) {
+			seen[reading.Sensor] = reading.At
+		}
+	}
+	return seen
+}
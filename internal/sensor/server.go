@@ -0,0 +1,165 @@
+package sensor
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxBodySize bounds a request body; a batch of readings is a few hundred
+// bytes.
+const maxBodySize = 64 << 10
+
+// maxBatchSize bounds the readings in one request.
+const maxBatchSize = 100
+
+// Server is the HTTP endpoint sensors push readings to:
+//
+//	POST /readings
+//	Authorization: Bearer <token>
+//	{"sensor": "kitchen", "metric": "temperature", "value": 21.5, "unit": "°C"}
+//
+// The body can also be an array of readings. Readings are timestamped on
+// arrival.
+type Server struct {
+	server   *http.Server
+	token    string
+	registry *Registry
+}
+
+// NewServer returns a server listening on addr, e.g. ":8090". Every request
+// must carry token.
+func NewServer(addr, token string, registry *Registry) *Server {
+	s := &Server{token: token, registry: registry}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/readings", s.handleReadings)
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	return s
+}
+
+// Run serves until ctx is cancelled.
+func (s *Server) Run(ctx context.Context) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("error serving sensor endpoint: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return s.server.Shutdown(shutdownCtx)
+	}
+}
+
+// Handler returns the server's HTTP handler, e.g. for httptest.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) handleReadings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	readings, err := decodeReadings(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	now := time.Now()
+	for i := range readings {
+		if err := readings[i].Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		readings[i].At = now
+	}
+	if err := s.registry.Record(readings); err != nil {
+		log.Printf("Error recording sensor readings: %v", err)
+		http.Error(w, "error storing readings", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readingJSON is a reading as sent by a sensor. Value is a pointer so a
+// missing value is an error rather than 0.
+type readingJSON struct {
+	Sensor string   `json:"sensor"`
+	Metric string   `json:"metric"`
+	Value  *float64 `json:"value"`
+	Unit   string   `json:"unit"`
+}
+
+// decodeReadings reads a single reading or an array of readings.
+func decodeReadings(body io.Reader) ([]Reading, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+
+	var batch []readingJSON
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &batch)
+	} else {
+		var single readingJSON
+		err = json.Unmarshal(data, &single)
+		batch = []readingJSON{single}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	if len(batch) == 0 {
+		return nil, errors.New("no readings")
+	}
+	if len(batch) > maxBatchSize {
+		return nil, fmt.Errorf("too many readings: at most %d per request", maxBatchSize)
+	}
+
+	readings := make([]Reading, len(batch))
+	for i, r := range batch {
+		if r.Value == nil {
+			return nil, fmt.Errorf("reading %d has no value", i+1)
+		}
+		readings[i] = Reading{Sensor: r.Sensor, Metric: r.Metric, Value: *r.Value, Unit: r.Unit}
+	}
+	return readings, nil
+}
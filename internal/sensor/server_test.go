@@ -0,0 +1,257 @@
+package sensor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore records readings in memory.
+type memoryStore struct {
+	mu       sync.Mutex
+	readings []Reading
+	err      error
+}
+
+func (s *memoryStore) AddSensorReading(sensor, metric, unit string, value float64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.readings = append(s.readings, Reading{Sensor: sensor, Metric: metric, Value: value, Unit: unit, At: at})
+	return nil
+}
+
+const testToken = "s3cret"
+
+func TestServerHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		auth       string
+		body       string
+		storeErr   error
+		wantStatus int
+		wantBody   string
+		want       []string // stored metric names
+	}{
+		{
+			name:       "single reading",
+			body:       `{"sensor": "kitchen", "metric": "temperature", "value": 21.5, "unit": "°C"}`,
+			wantStatus: http.StatusNoContent,
+			want:       []string{"sensor.kitchen.temperature"},
+		},
+		{
+			name:       "batch",
+			body:       `[{"sensor": "kitchen", "metric": "temperature", "value": 21.5}, {"sensor": "kitchen", "metric": "humidity", "value": 0}]`,
+			wantStatus: http.StatusNoContent,
+			want:       []string{"sensor.kitchen.temperature", "sensor.kitchen.humidity"},
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "no token",
+			auth:       "-",
+			body:       `{"sensor": "kitchen", "metric": "temperature", "value": 21.5}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong token",
+			auth:       "Bearer guess",
+			body:       `{"sensor": "kitchen", "metric": "temperature", "value": 21.5}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "token without scheme",
+			auth:       testToken,
+			body:       `{"sensor": "kitchen", "metric": "temperature", "value": 21.5}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "body too large",
+			body:       `{"sensor": "kitchen", "metric": "temperature", "value": 21.5, "unit": "` + strings.Repeat("x", maxBodySize) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "missing value",
+			body:       `{"sensor": "kitchen", "metric": "temperature"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "reading 1 has no value",
+		},
+		{
+			name:       "missing value in batch",
+			body:       `[{"sensor": "a", "metric": "t", "value": 1}, {"sensor": "b", "metric": "t", "value": null}]`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "reading 2 has no value",
+		},
+		{
+			name:       "invalid sensor name",
+			body:       `{"sensor": "kitchen.sink", "metric": "temperature", "value": 1}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid sensor name",
+		},
+		{
+			// Nothing in a batch is stored if one reading is bad
+			name:       "invalid metric in batch",
+			body:       `[{"sensor": "a", "metric": "t", "value": 1}, {"sensor": "a", "metric": "", "value": 1}]`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid metric name",
+		},
+		{
+			name:       "unit too long",
+			body:       `{"sensor": "a", "metric": "t", "value": 1, "unit": "` + strings.Repeat("u", maxUnitLength+1) + `"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid unit",
+		},
+		{
+			name:       "invalid JSON",
+			body:       `{"sensor": "a",`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid JSON",
+		},
+		{
+			name:       "empty batch",
+			body:       `[]`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "no readings",
+		},
+		{
+			name:       "batch too large",
+			body:       "[" + strings.TrimSuffix(strings.Repeat(`{"sensor": "a", "metric": "t", "value": 1},`, maxBatchSize+1), ",") + "]",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "too many readings",
+		},
+		{
+			name:       "store fails",
+			body:       `{"sensor": "kitchen", "metric": "temperature", "value": 21.5}`,
+			storeErr:   errors.New("disk full"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "error storing readings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{err: tt.storeErr}
+			registry := NewRegistry(store, time.Hour)
+			handler := NewServer(":0", testToken, registry).Handler()
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/readings", strings.NewReader(tt.body))
+			switch tt.auth {
+			case "":
+				req.Header.Set("Authorization", "Bearer "+testToken)
+			case "-":
+			default:
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body %q, want it to contain %q", rec.Body, tt.wantBody)
+			}
+
+			if len(store.readings) != len(tt.want) {
+				t.Fatalf("stored %+v, want %v", store.readings, tt.want)
+			}
+			for i, name := range tt.want {
+				if store.readings[i].Metric != name || store.readings[i].At.IsZero() {
+					t.Errorf("stored %+v, want %s", store.readings[i], name)
+				}
+			}
+			if got := len(registry.Latest()); got != len(tt.want) {
+				t.Errorf("registry has %d readings, want %d", got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestServerUnknownPath(t *testing.T) {
+	handler := NewServer(":0", testToken, NewRegistry(&memoryStore{}, time.Hour)).Handler()
+	req := httptest.NewRequest(http.MethodPost, "/other", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404", rec.Code)
+	}
+}
+
+func TestRegistryEvents(t *testing.T) {
+	registry := NewRegistry(&memoryStore{}, time.Hour)
+	now := time.Now()
+
+	registry.Restore([]Reading{
+		{Sensor: "attic", Metric: "t", Value: 1, At: now.Add(-2 * time.Hour)},
+		{Sensor: "porch", Metric: "t", Value: 2, At: now.Add(-90 * time.Minute)},
+		{Sensor: "porch", Metric: "h", Value: 3, At: now.Add(-time.Minute)},
+	})
+	// Sensors already silent at startup are not announced again
+	if events, _ := registry.Events(); len(events) != 0 {
+		t.Errorf("Events() after Restore = %q", events)
+	}
+	metrics, _ := registry.Metrics()
+	if len(metrics) != 1 || metrics["sensor.porch.h"] != 3 {
+		t.Errorf("Metrics() = %v, want only sensor.porch.h", metrics)
+	}
+
+	if err := registry.Record([]Reading{{Sensor: "attic", Metric: "t", Value: 4, At: now}}); err != nil {
+		t.Fatal(err)
+	}
+	events, _ := registry.Events()
+	if len(events) != 1 || !strings.Contains(events[0], "attic is reporting again") {
+		t.Errorf("Events() after a new reading = %q", events)
+	}
+
+	registry.mu.Lock()
+	for name, reading := range registry.latest {
+		if reading.Sensor == "porch" {
+			reading.At = now.Add(-2 * time.Hour)
+			registry.latest[name] = reading
+		}
+	}
+	registry.mu.Unlock()
+	events, _ = registry.Events()
+	if len(events) != 1 || !strings.Contains(events[0], "porch has not reported") {
+		t.Errorf("Events() after porch went quiet = %q", events)
+	}
+	if events, _ := registry.Events(); len(events) != 0 {
+		t.Errorf("silent sensor reported twice: %q", events)
+	}
+}
+
+func TestParseMetricName(t *testing.T) {
+	tests := []struct {
+		name           string
+		sensor, metric string
+		ok             bool
+	}{
+		{"sensor.kitchen.temperature", "kitchen", "temperature", true},
+		{"sensor.esp-1.co2_ppm", "esp-1", "co2_ppm", true},
+		{"sensor.kitchen", "", "", false},
+		{"sensor.kitchen.temp.max", "", "", false},
+		{"cpu_temp", "", "", false},
+		{"sensor..t", "", "", false},
+	}
+	for _, tt := range tests {
+		sensor, metric, ok := ParseMetricName(tt.name)
+		if sensor != tt.sensor || metric != tt.metric || ok != tt.ok {
+			t.Errorf("ParseMetricName(%q) = %q, %q, %v", tt.name, sensor, metric, ok)
+		}
+	}
+}
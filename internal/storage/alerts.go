@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type AlertRule struct {
+	ID         int64
+	Metric     string
+	Op         string
+	Threshold  float64
+	For        time.Duration
+	Hysteresis float64
+	Cooldown   time.Duration
+	MutedUntil sql.NullTime
+	CreatedAt  time.Time
+}
+
+// SeedAlertRules inserts rules the first time it is called on a database
+// and does nothing afterwards, so rules deleted at runtime stay deleted. It
+// reports whether the rules were inserted.
+func (d *Database) SeedAlertRules(rules []*AlertRule) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seeded string
+	err = tx.QueryRow("SELECT value FROM app_state WHERE key = 'alert_rules_seeded'").Scan(&seeded)
+	if err == nil {
+		return false, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, fmt.Errorf("error checking alert rule seed state: %w", err)
+	}
+
+	for _, rule := range rules {
+		if _, err := insertAlertRule(tx, rule); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.Exec("INSERT INTO app_state (key, value) VALUES ('alert_rules_seeded', '1')"); err != nil {
+		return false, fmt.Errorf("error recording alert rule seed state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateAlertRule stores a new rule and returns its ID.
+func (d *Database) CreateAlertRule(rule *AlertRule) (int64, error) {
+	return insertAlertRule(d.db, rule)
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertAlertRule(db execer, rule *AlertRule) (int64, error) {
+	query := `
+		INSERT INTO alert_rules (
+			metric, op, threshold, for_seconds, hysteresis, cooldown_seconds
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := db.Exec(query,
+		rule.Metric,
+		rule.Op,
+		rule.Threshold,
+		int64(rule.For/time.Second),
+		rule.Hysteresis,
+		int64(rule.Cooldown/time.Second),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error creating alert rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error getting last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// ListAlertRules returns every alert rule ordered by ID.
+func (d *Database) ListAlertRules() ([]*AlertRule, error) {
+	query := `
+		SELECT id, metric, op, threshold, for_seconds, hysteresis,
+			   cooldown_seconds, muted_until, created_at
+		FROM alert_rules
+		ORDER BY id ASC
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*AlertRule
+	for rows.Next() {
+		rule := &AlertRule{}
+		var forSeconds, cooldownSeconds int64
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Metric,
+			&rule.Op,
+			&rule.Threshold,
+			&forSeconds,
+			&rule.Hysteresis,
+			&cooldownSeconds,
+			&rule.MutedUntil,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning alert rule: %w", err)
+		}
+		rule.For = time.Duration(forSeconds) * time.Second
+		rule.Cooldown = time.Duration(cooldownSeconds) * time.Second
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// MuteAlertRule silences a rule until the given time. A zero time unmutes it.
+func (d *Database) MuteAlertRule(id int64, until time.Time) error {
+	var mutedUntil interface{}
+	if !until.IsZero() {
+		mutedUntil = until.UTC().Format(timestampFormat)
+	}
+
+	result, err := d.db.Exec("UPDATE alert_rules SET muted_until = ? WHERE id = ?", mutedUntil, id)
+	if err != nil {
+		return fmt.Errorf("error muting alert rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+
+	return nil
+}
+
+// DeleteAlertRule deletes a rule
+func (d *Database) DeleteAlertRule(id int64) error {
+	result, err := d.db.Exec("DELETE FROM alert_rules WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting alert rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("alert rule not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package storage
+
+import "fmt"
+
+// AuditEntry records an admin action. Result is "ok" or the error the
+// action failed with.
+type AuditEntry struct {
+	UserID int64
+	Action string
+	Target string
+	Result string
+}
+
+// AddAuditEntry records an admin action. Unlike other history it is never
+// buffered: an action that happened must not go unrecorded.
+func (d *Database) AddAuditEntry(entry *AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (user_id, action, target, result)
+		VALUES (?, ?, ?, ?)
+	`
+
+	if _, err := d.db.Exec(query, entry.UserID, entry.Action, entry.Target, entry.Result); err != nil {
+		return fmt.Errorf("error adding audit entry: %w", err)
+	}
+	return nil
+}
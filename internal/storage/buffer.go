@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// maxPendingWrites bounds how many low-value writes are held in memory before
+// a flush is forced, regardless of the flush interval.
+const maxPendingWrites = 500
+
+// maxRequeuedWrites bounds the queue while flushes keep failing, e.g. while
+// another process holds the database lock. Past it the oldest writes are
+// dropped.
+const maxRequeuedWrites = 10 * maxPendingWrites
+
+type pendingWrite struct {
+	query string
+	args  []interface{}
+}
+
+// writeBuffer queues low-value writes (trigger timestamps, history rows, ...)
+// and applies them in a single transaction on every flush. A batch whose
+// transaction cannot be started or committed is queued again for the next
+// flush. Anything still queued when the process dies without a clean
+// shutdown is lost.
+type writeBuffer struct {
+	mu      sync.Mutex
+	db      *sql.DB
+	pending []pendingWrite
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newWriteBuffer(db *sql.DB, interval time.Duration) *writeBuffer {
+	b := &writeBuffer{
+		db:   db,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go b.loop(interval)
+	return b
+}
+
+func (b *writeBuffer) add(query string, args ...interface{}) {
+	b.mu.Lock()
+	b.pending = append(b.pending, pendingWrite{query: query, args: args})
+	full := len(b.pending) >= maxPendingWrites
+	b.mu.Unlock()
+
+	if full {
+		if err := b.flush(); err != nil {
+			log.Printf("Error flushing write buffer: %v", err)
+		}
+	}
+}
+
+func (b *writeBuffer) loop(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.flush(); err != nil {
+				log.Printf("Error flushing write buffer: %v", err)
+			}
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// flush writes every queued statement in one transaction. Statements that
+// fail are skipped so one bad row cannot hold back the batch; if the
+// transaction itself fails the whole batch is queued again.
+func (b *writeBuffer) flush() error {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		b.requeue(batch)
+		return fmt.Errorf("error beginning flush transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dropped := 0
+	var firstErr error
+	for _, w := range batch {
+		if _, err := tx.Exec(w.query, w.args...); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%q: %w", w.query, err)
+			}
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		log.Printf("Dropped %d of %d buffered writes (first: %v)", dropped, len(batch), firstErr)
+	}
+
+	if err := tx.Commit(); err != nil {
+		// e.g. SQLITE_BUSY while the admin CLI or a backup holds the lock
+		b.requeue(batch)
+		return fmt.Errorf("error committing flush transaction: %w", err)
+	}
+	return nil
+}
+
+// requeue puts a batch that could not be written back in front of the
+// queue so the next flush retries it.
+func (b *writeBuffer) requeue(batch []pendingWrite) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = append(batch, b.pending...)
+	if over := len(b.pending) - maxRequeuedWrites; over > 0 {
+		log.Printf("Write buffer is full, dropping the %d oldest buffered writes", over)
+		b.pending = b.pending[over:]
+	}
+}
+
+// close stops the flush loop and writes out whatever is still pending.
+func (b *writeBuffer) close() error {
+	close(b.stop)
+	<-b.done
+	return b.flush()
+}
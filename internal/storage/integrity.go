@@ -0,0 +1,442 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Integrity check levels accepted in Options.IntegrityCheck.
+const (
+	IntegrityQuick = "quick" // PRAGMA quick_check, O(N) and skips index checks
+	IntegrityFull  = "full"  // PRAGMA integrity_check
+	IntegrityOff   = "off"
+)
+
+// Recovery methods reported in RecoveryReport.Method.
+const (
+	RecoveredBySalvage = "salvage"
+	RecoveredByBackup  = "backup"
+	RecoveredEmpty     = "empty"
+)
+
+// RecoveryReport describes what happened when a corrupt database was found
+// at startup.
+type RecoveryReport struct {
+	Problems    []string         // what the integrity check reported
+	DamagedPath string           // where the damaged original was moved
+	Method      string           // how the new database was produced
+	Salvaged    map[string]int64 // rows copied out of the damaged file, per table
+	Incomplete  []string         // tables that could only be partly read
+	BackupPath  string           // backup restored, if Method is RecoveredByBackup
+	BackupRows  int64            // rows in the restored backup
+}
+
+func (r *RecoveryReport) String() string {
+	var b strings.Builder
+	b.WriteString("⚠️ The database failed its integrity check at startup.\n")
+	for i, p := range r.Problems {
+		if i == 3 {
+			b.WriteString(fmt.Sprintf("  … and %d more problems\n", len(r.Problems)-i))
+			break
+		}
+		b.WriteString("  " + p + "\n")
+	}
+	b.WriteString(fmt.Sprintf("The damaged file was kept at %s\n", r.DamagedPath))
+
+	switch r.Method {
+	case RecoveredBySalvage:
+		var total int64
+		for _, n := range r.Salvaged {
+			total += n
+		}
+		b.WriteString(fmt.Sprintf("Salvaged %d rows into a fresh database.", total))
+		if len(r.Incomplete) > 0 {
+			b.WriteString(fmt.Sprintf("\nSome rows could not be read from: %s", strings.Join(r.Incomplete, ", ")))
+		}
+	case RecoveredByBackup:
+		var salvaged int64
+		for _, n := range r.Salvaged {
+			salvaged += n
+		}
+		if salvaged == 0 {
+			b.WriteString(fmt.Sprintf("Nothing could be salvaged; restored backup %s.", filepath.Base(r.BackupPath)))
+		} else {
+			b.WriteString(fmt.Sprintf("Only %d rows could be salvaged; restored backup %s with %d rows instead.",
+				salvaged, filepath.Base(r.BackupPath), r.BackupRows))
+		}
+	default:
+		b.WriteString("Nothing could be salvaged and no backup was found; started with an empty database.")
+	}
+
+	return b.String()
+}
+
+// checkIntegrity runs the requested check against the file at dbPath and
+// returns the problems it found. A missing file has no problems.
+func checkIntegrity(dbPath, level string) ([]string, error) {
+	if level == IntegrityOff {
+		return nil, nil
+	}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database for integrity check: %w", err)
+	}
+	defer db.Close()
+
+	pragma := "PRAGMA quick_check"
+	if level == IntegrityFull {
+		pragma = "PRAGMA integrity_check"
+	}
+
+	rows, err := db.Query(pragma)
+	if err != nil {
+		if isCorruption(err) {
+			return []string{err.Error()}, nil
+		}
+		return nil, fmt.Errorf("error running integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("error reading integrity check result: %w", err)
+		}
+		for _, problem := range strings.Split(line, "\n") {
+			if problem = strings.TrimSpace(problem); problem != "" && problem != "ok" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		if isCorruption(err) {
+			return append(problems, err.Error()), nil
+		}
+		return nil, fmt.Errorf("error reading integrity check result: %w", err)
+	}
+
+	return problems, nil
+}
+
+// isCorruption reports whether err is SQLite saying the file is damaged, as
+// opposed to e.g. being locked.
+func isCorruption(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "malformed") ||
+		strings.Contains(msg, "not a database") ||
+		strings.Contains(msg, "corrupt")
+}
+
+// recoverDatabase moves the damaged file aside, builds a fresh database at
+// dbPath and fills it with whatever rows can still be read. If some tables
+// could only be partly read, the newest backup in backupDir is restored
+// instead when it holds at least as many rows.
+func recoverDatabase(dbPath, backupDir string, problems []string) (*RecoveryReport, error) {
+	report := &RecoveryReport{
+		Problems:    problems,
+		DamagedPath: fmt.Sprintf("%s.corrupt-%s", dbPath, time.Now().Format("20060102-150405")),
+		Salvaged:    make(map[string]int64),
+	}
+	// Never overwrite an earlier damaged copy
+	for i := 2; fileExists(report.DamagedPath); i++ {
+		report.DamagedPath = fmt.Sprintf("%s.corrupt-%s-%d", dbPath, time.Now().Format("20060102-150405"), i)
+	}
+
+	// Keep the WAL and shared-memory files with the damaged original, since
+	// the WAL may hold rows that never made it into the main file
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(dbPath+suffix, report.DamagedPath+suffix); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error moving damaged database aside: %w", err)
+		}
+	}
+
+	salvaged, err := salvage(report.DamagedPath, dbPath, report)
+	if err != nil {
+		return nil, err
+	}
+	// A complete salvage is newer than any backup
+	if salvaged > 0 && len(report.Incomplete) == 0 {
+		report.Method = RecoveredBySalvage
+		return report, nil
+	}
+
+	// A partial salvage only wins over a backup that holds fewer rows
+	backup, backupRows := latestBackup(backupDir, dbPath)
+	if backup == "" || backupRows < salvaged {
+		report.Method = RecoveredBySalvage
+		if salvaged == 0 {
+			report.Method = RecoveredEmpty
+		}
+		return report, nil
+	}
+
+	// Replace the fresh database with the backup
+	for _, suffix := range []string{"-wal", "-shm"} {
+		os.Remove(dbPath + suffix)
+	}
+	if err := copyFile(backup, dbPath); err != nil {
+		return nil, fmt.Errorf("error restoring backup %s: %w", backup, err)
+	}
+	report.Method = RecoveredByBackup
+	report.BackupPath = backup
+	report.BackupRows = backupRows
+	return report, nil
+}
+
+// salvage creates a fresh, migrated database at dst and copies every
+// readable row of the known tables out of src. It returns how many rows
+// were recovered.
+func salvage(src, dst string, report *RecoveryReport) (int64, error) {
+	fresh, err := sql.Open("sqlite", dst)
+	if err != nil {
+		return 0, fmt.Errorf("error creating fresh database: %w", err)
+	}
+	defer fresh.Close()
+
+	if err := (&Database{db: fresh}).migrate(); err != nil {
+		return 0, fmt.Errorf("error migrating fresh database: %w", err)
+	}
+
+	damaged, err := sql.Open("sqlite", "file:"+src+"?mode=ro")
+	if err != nil {
+		return 0, nil
+	}
+	defer damaged.Close()
+
+	tables, err := tableNames(fresh)
+	if err != nil {
+		return 0, err
+	}
+
+	var recovered int64
+	for _, table := range tables {
+		// The fresh file already records its own migrations
+		if table == "schema_migrations" {
+			continue
+		}
+
+		n, complete := copyRows(damaged, fresh, table)
+		if n > 0 {
+			report.Salvaged[table] = n
+			recovered += n
+		}
+		if !complete {
+			report.Incomplete = append(report.Incomplete, table)
+		}
+	}
+
+	return recovered, nil
+}
+
+func tableNames(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("error scanning table name: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func columnNames(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%q)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// copyRows copies the columns table has in both databases, row by row, and
+// stops at the first unreadable row. It returns how many rows were copied
+// and whether the whole table was read.
+func copyRows(src, dst *sql.DB, table string) (int64, bool) {
+	dstColumns, err := columnNames(dst, table)
+	if err != nil {
+		return 0, false
+	}
+	srcColumns, err := columnNames(src, table)
+	if err != nil || len(srcColumns) == 0 {
+		return 0, false
+	}
+
+	var columns []string
+	for name := range dstColumns {
+		if srcColumns[name] {
+			columns = append(columns, fmt.Sprintf("%q", name))
+		}
+	}
+	sort.Strings(columns)
+	if len(columns) == 0 {
+		return 0, true
+	}
+
+	list := strings.Join(columns, ", ")
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insert := fmt.Sprintf("INSERT OR IGNORE INTO %q (%s) VALUES (%s)", table, list, placeholders)
+
+	rows, err := src.Query(fmt.Sprintf("SELECT %s FROM %q", list, table))
+	if err != nil {
+		log.Printf("Salvage: cannot read %s: %v", table, err)
+		return 0, false
+	}
+	defer rows.Close()
+
+	var copied int64
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			log.Printf("Salvage: skipping unreadable row in %s: %v", table, err)
+			continue
+		}
+		result, err := dst.Exec(insert, values...)
+		if err != nil {
+			log.Printf("Salvage: skipping row in %s: %v", table, err)
+			continue
+		}
+		// OR IGNORE silently drops rows that break constraints, which is
+		// what damaged rows usually do
+		if n, _ := result.RowsAffected(); n > 0 {
+			copied++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Salvage: stopped reading %s after %d rows: %v", table, copied, err)
+		return copied, false
+	}
+
+	return copied, true
+}
+
+// latestBackup returns the newest backup of dbPath in dir that passes a
+// quick check and how many rows it holds, or "" if there is none.
+func latestBackup(dir, dbPath string) (string, int64) {
+	matches := backupFiles(dir, dbPath)
+	for i := len(matches) - 1; i >= 0; i-- {
+		path := matches[i]
+		problems, err := checkIntegrity(path, IntegrityQuick)
+		if err != nil || len(problems) > 0 {
+			log.Printf("Skipping damaged backup %s", path)
+			continue
+		}
+		rows, err := countRows(path)
+		if err != nil {
+			log.Printf("Skipping unreadable backup %s: %v", path, err)
+			continue
+		}
+		return path, rows
+	}
+	return "", 0
+}
+
+// backupFiles returns the backups of dbPath in dir, oldest first. Names
+// embed a sortable timestamp, see BackupFileName.
+func backupFiles(dir, dbPath string) []string {
+	base := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+	matches, err := filepath.Glob(filepath.Join(dir, base+"-*.db"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// countRows returns the total rows in the database at path, leaving out
+// the migration records.
+func countRows(path string) (int64, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	tables, err := tableNames(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, table := range tables {
+		if table == "schema_migrations" {
+			continue
+		}
+		var n int64
+		if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %q", table)).Scan(&n); err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
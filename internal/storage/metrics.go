@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// MetricSample is one stored reading. Source tells readings of the same
+// metric apart, e.g. the mountpoint for disk usage.
+type MetricSample struct {
+	Source     string
+	Metric     string
+	Value      float64
+	RecordedAt time.Time
+}
+
+// AddMetricSample records a reading. Samples are low-value writes and are
+// buffered in SD-card mode.
+func (d *Database) AddMetricSample(source, metric string, value float64, at time.Time) error {
+	query := `
+		INSERT INTO metric_samples (source, metric, value, recorded_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	if err := d.execLowValue(query, source, metric, value, at.UTC().Format(timestampFormat)); err != nil {
+		return fmt.Errorf("error adding metric sample: %w", err)
+	}
+	return nil
+}
+
+// GetMetricSamples returns the readings of a metric since the given time,
+// grouped by source and oldest first.
+func (d *Database) GetMetricSamples(metric string, since time.Time) (map[string][]MetricSample, error) {
+	// Include samples still waiting in the write buffer
+	if err := d.Flush(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT source, value, recorded_at
+		FROM metric_samples
+		WHERE metric = ? AND recorded_at >= ?
+		ORDER BY recorded_at ASC
+	`
+
+	rows, err := d.db.Query(query, metric, since.UTC().Format(timestampFormat))
+	if err != nil {
+		return nil, fmt.Errorf("error querying metric samples: %w", err)
+	}
+	defer rows.Close()
+
+	samples := make(map[string][]MetricSample)
+	for rows.Next() {
+		s := MetricSample{Metric: metric}
+		if err := rows.Scan(&s.Source, &s.Value, &s.RecordedAt); err != nil {
+			return nil, fmt.Errorf("error scanning metric sample: %w", err)
+		}
+		samples[s.Source] = append(samples[s.Source], s)
+	}
+
+	return samples, rows.Err()
+}
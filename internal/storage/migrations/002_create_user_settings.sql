@@ -0,0 +1,17 @@
+-- migrations/002_create_user_settings.sql
+
+-- Per-user preferences, kept separately for every chat the user talks to
+-- the bot in
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INTEGER NOT NULL,
+    chat_id INTEGER NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'Local',
+    temperature_unit TEXT NOT NULL CHECK(temperature_unit IN ('C', 'F')) DEFAULT 'C',
+    language TEXT NOT NULL DEFAULT 'en',
+    default_interval INTEGER NOT NULL DEFAULT 120, -- in minutes
+    silent_notifications INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, chat_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_settings_chat ON user_settings(chat_id);
@@ -0,0 +1,21 @@
+-- migrations/003_create_alert_rules.sql
+
+-- Alert rules managed at runtime with /alert_add and friends
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    metric TEXT NOT NULL,
+    op TEXT NOT NULL CHECK(op IN ('>', '<')),
+    threshold REAL NOT NULL,
+    for_seconds INTEGER NOT NULL DEFAULT 0,
+    hysteresis REAL NOT NULL DEFAULT 0,
+    cooldown_seconds INTEGER NOT NULL DEFAULT 0,
+    muted_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Small key/value store for one-off application state, such as whether
+-- the configured default alert rules have been imported
+CREATE TABLE IF NOT EXISTS app_state (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
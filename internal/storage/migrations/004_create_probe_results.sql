@@ -0,0 +1,14 @@
+-- migrations/004_create_probe_results.sql
+
+-- One row per reachability probe check
+CREATE TABLE IF NOT EXISTS probe_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    probe TEXT NOT NULL,
+    checked_at TIMESTAMP NOT NULL,
+    up INTEGER NOT NULL,
+    latency_ms REAL NOT NULL,
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_probe_results_probe_checked_at
+    ON probe_results(probe, checked_at);
@@ -0,0 +1,13 @@
+-- migrations/005_create_metric_samples.sql
+
+-- Periodic readings kept for trends, such as disk usage per mountpoint
+CREATE TABLE IF NOT EXISTS metric_samples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,
+    metric TEXT NOT NULL,
+    value REAL NOT NULL,
+    recorded_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_metric_samples_metric_recorded_at
+    ON metric_samples(metric, recorded_at);
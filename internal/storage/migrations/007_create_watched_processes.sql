@@ -0,0 +1,10 @@
+-- migrations/007_create_watched_processes.sql
+
+-- Processes the watchdog expects to be running, managed with /watch
+CREATE TABLE IF NOT EXISTS watched_processes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    match_type TEXT NOT NULL CHECK(match_type IN ('name', 'cmdline', 'pidfile')),
+    pattern TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
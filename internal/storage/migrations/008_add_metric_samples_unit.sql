@@ -0,0 +1,4 @@
+-- migrations/008_add_metric_samples_unit.sql
+
+-- Readings pushed by external sensors carry their unit, e.g. "°C"
+ALTER TABLE metric_samples ADD COLUMN unit TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProbeStats summarises a probe's checks over a period.
+type ProbeStats struct {
+	Checks     int64
+	Up         int64
+	AvgLatency time.Duration // over successful checks only
+}
+
+// UptimePercent is the share of checks that succeeded.
+func (s ProbeStats) UptimePercent() float64 {
+	if s.Checks == 0 {
+		return 0
+	}
+	return float64(s.Up) / float64(s.Checks) * 100
+}
+
+// AddProbeResult records one probe check. Results are low-value writes and
+// are buffered in SD-card mode.
+func (d *Database) AddProbeResult(probe string, at time.Time, up bool, latency time.Duration, errMsg string) error {
+	query := `
+		INSERT INTO probe_results (probe, checked_at, up, latency_ms, error)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	err := d.execLowValue(query,
+		probe,
+		at.UTC().Format(timestampFormat),
+		up,
+		float64(latency)/float64(time.Millisecond),
+		errMsg,
+	)
+	if err != nil {
+		return fmt.Errorf("error adding probe result: %w", err)
+	}
+
+	return nil
+}
+
+// GetProbeStats summarises the checks of a probe since the given time.
+func (d *Database) GetProbeStats(probe string, since time.Time) (*ProbeStats, error) {
+	// Include results still waiting in the write buffer
+	if err := d.Flush(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT COUNT(*),
+			COALESCE(SUM(up), 0),
+			COALESCE(AVG(CASE WHEN up THEN latency_ms END), 0)
+		FROM probe_results
+		WHERE probe = ? AND checked_at >= ?
+	`
+
+	var stats ProbeStats
+	var avgMs float64
+	err := d.db.QueryRow(query, probe, since.UTC().Format(timestampFormat)).
+		Scan(&stats.Checks, &stats.Up, &avgMs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting probe stats: %w", err)
+	}
+	stats.AvgLatency = time.Duration(avgMs * float64(time.Millisecond))
+
+	return &stats, nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// retentionTables lists the tables a retention policy may apply to and the
+// column holding each row's timestamp.
+var retentionTables = map[string]string{
+	"reminder_history": "triggered_at",
+	"probe_results":    "checked_at",
+	"metric_samples":   "recorded_at",
+	"audit_log":        "created_at",
+}
+
+// RetentionPolicy limits how much of a table is kept. A zero MaxAge or
+// MaxRows means no limit of that kind.
+type RetentionPolicy struct {
+	Table   string
+	MaxAge  time.Duration
+	MaxRows int
+}
+
+type PurgeResult struct {
+	Table   string
+	Deleted int64
+}
+
+type TableStats struct {
+	Name string
+	Rows int64
+}
+
+// ValidateRetention checks that every policy names a purgeable table.
+func ValidateRetention(policies []RetentionPolicy) error {
+	for _, p := range policies {
+		if _, ok := retentionTables[p.Table]; !ok {
+			return fmt.Errorf("retention is not supported for table %q", p.Table)
+		}
+		if p.MaxAge < 0 || p.MaxRows < 0 {
+			return fmt.Errorf("invalid retention for table %q: limits must not be negative", p.Table)
+		}
+	}
+	return nil
+}
+
+// Purge deletes rows that fall outside the given policies and reports how
+// many rows were removed from each table.
+func (d *Database) Purge(policies []RetentionPolicy) ([]PurgeResult, error) {
+	if err := ValidateRetention(policies); err != nil {
+		return nil, err
+	}
+
+	// Buffered rows must be on disk before they can be counted or removed
+	if err := d.Flush(); err != nil {
+		return nil, fmt.Errorf("error flushing before purge: %w", err)
+	}
+
+	deleted := make(map[string]int64)
+	for _, p := range policies {
+		column := retentionTables[p.Table]
+
+		if p.MaxAge > 0 {
+			cutoff := time.Now().UTC().Add(-p.MaxAge).Format(timestampFormat)
+			query := fmt.Sprintf("DELETE FROM %s WHERE %s < ?", p.Table, column)
+			result, err := d.db.Exec(query, cutoff)
+			if err != nil {
+				return nil, fmt.Errorf("error purging %s by age: %w", p.Table, err)
+			}
+			n, _ := result.RowsAffected()
+			deleted[p.Table] += n
+		}
+
+		if p.MaxRows > 0 {
+			query := fmt.Sprintf(
+				"DELETE FROM %[1]s WHERE id NOT IN (SELECT id FROM %[1]s ORDER BY id DESC LIMIT ?)",
+				p.Table)
+			result, err := d.db.Exec(query, p.MaxRows)
+			if err != nil {
+				return nil, fmt.Errorf("error purging %s by row count: %w", p.Table, err)
+			}
+			n, _ := result.RowsAffected()
+			deleted[p.Table] += n
+		}
+	}
+
+	var results []PurgeResult
+	for table, n := range deleted {
+		results = append(results, PurgeResult{Table: table, Deleted: n})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Table < results[j].Table
+	})
+
+	return results, nil
+}
+
+// TableStats returns the row count of every table in the database.
+func (d *Database) TableStats() ([]TableStats, error) {
+	rows, err := d.db.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning table name: %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	var stats []TableStats
+	for _, name := range names {
+		var count int64
+		if err := d.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %q", name)).Scan(&count); err != nil {
+			return nil, fmt.Errorf("error counting rows in %s: %w", name, err)
+		}
+		stats = append(stats, TableStats{Name: name, Rows: count})
+	}
+
+	return stats, nil
+}
+
+// FileSizes returns the size in bytes of the database file and of its WAL
+// file, which is zero outside WAL mode.
+func (d *Database) FileSizes() (dbSize int64, walSize int64, err error) {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading database file size: %w", err)
+	}
+
+	if walInfo, err := os.Stat(d.path + "-wal"); err == nil {
+		walSize = walInfo.Size()
+	}
+
+	return info.Size(), walSize, nil
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// sensorMetricPrefix starts the metric_samples name of every sensor
+// reading, which is "sensor.<sensor>.<metric>".
+const sensorMetricPrefix = "sensor."
+
+// SensorReading is the latest stored reading of one sensor metric.
+type SensorReading struct {
+	Sensor     string
+	Metric     string // full name, e.g. "sensor.kitchen.temperature"
+	Value      float64
+	Unit       string
+	RecordedAt time.Time
+}
+
+// SensorRange is the spread of a sensor metric's readings over a period.
+type SensorRange struct {
+	Min, Avg, Max float64
+	Count         int64
+}
+
+// AddSensorReading records a reading pushed by an external sensor under
+// its full metric name. Readings are low-value writes and are buffered in
+// SD-card mode.
+func (d *Database) AddSensorReading(sensor, metric, unit string, value float64, at time.Time) error {
+	query := `
+		INSERT INTO metric_samples (source, metric, value, unit, recorded_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	if err := d.execLowValue(query, sensor, metric, value, unit, at.UTC().Format(timestampFormat)); err != nil {
+		return fmt.Errorf("error adding sensor reading: %w", err)
+	}
+	return nil
+}
+
+// LatestSensorReadings returns the newest reading of every sensor metric.
+func (d *Database) LatestSensorReadings() ([]SensorReading, error) {
+	// Include readings still waiting in the write buffer. Timestamps have
+	// one-second resolution, so the newest row wins by ID.
+	if err := d.Flush(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT source, metric, value, unit, recorded_at
+		FROM metric_samples
+		WHERE id IN (
+			SELECT MAX(id)
+			FROM metric_samples
+			WHERE metric LIKE ? || '%'
+			GROUP BY metric
+		)
+		ORDER BY metric
+	`
+
+	rows, err := d.db.Query(query, sensorMetricPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sensor readings: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []SensorReading
+	for rows.Next() {
+		var r SensorReading
+		if err := rows.Scan(&r.Sensor, &r.Metric, &r.Value, &r.Unit, &r.RecordedAt); err != nil {
+			return nil, fmt.Errorf("error scanning sensor reading: %w", err)
+		}
+		readings = append(readings, r)
+	}
+
+	return readings, rows.Err()
+}
+
+// GetSensorRanges returns the min, average and max of every sensor metric
+// since the given time, keyed by full metric name.
+func (d *Database) GetSensorRanges(since time.Time) (map[string]SensorRange, error) {
+	if err := d.Flush(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT metric, MIN(value), AVG(value), MAX(value), COUNT(*)
+		FROM metric_samples
+		WHERE metric LIKE ? || '%' AND recorded_at >= ?
+		GROUP BY metric
+	`
+
+	rows, err := d.db.Query(query, sensorMetricPrefix, since.UTC().Format(timestampFormat))
+	if err != nil {
+		return nil, fmt.Errorf("error querying sensor ranges: %w", err)
+	}
+	defer rows.Close()
+
+	ranges := make(map[string]SensorRange)
+	for rows.Next() {
+		var metric string
+		var r SensorRange
+		if err := rows.Scan(&metric, &r.Min, &r.Avg, &r.Max, &r.Count); err != nil {
+			return nil, fmt.Errorf("error scanning sensor range: %w", err)
+		}
+		ranges[metric] = r
+	}
+
+	return ranges, rows.Err()
+}
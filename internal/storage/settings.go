@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting keys accepted by Settings.Set.
+const (
+	SettingTimezone        = "timezone"
+	SettingTemperatureUnit = "unit"
+	SettingLanguage        = "language"
+	SettingDefaultInterval = "interval"
+	SettingSilent          = "silent"
+)
+
+// SupportedLanguages lists the accepted values for Settings.Language.
+var SupportedLanguages = []string{"en", "ml"}
+
+// Settings holds one user's preferences in one chat.
+type Settings struct {
+	UserID              int64
+	ChatID              int64
+	Timezone            string // IANA name, or "Local" for the Pi's zone
+	TemperatureUnit     string // "C" or "F"
+	Language            string
+	DefaultInterval     int // in minutes
+	SilentNotifications bool
+	UpdatedAt           time.Time
+}
+
+// DefaultSettings returns the settings used until a user changes anything.
+func DefaultSettings(userID, chatID int64) *Settings {
+	return &Settings{
+		UserID:          userID,
+		ChatID:          chatID,
+		Timezone:        "Local",
+		TemperatureUnit: "C",
+		Language:        "en",
+		DefaultInterval: 120,
+	}
+}
+
+// Location returns the settings' time zone, falling back to the Pi's local
+// zone if the stored name cannot be loaded.
+func (s *Settings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Set validates value and assigns it to the setting named key.
+func (s *Settings) Set(key, value string) error {
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case SettingTimezone:
+		if _, err := time.LoadLocation(value); err != nil {
+			return fmt.Errorf("unknown time zone %q", value)
+		}
+		s.Timezone = value
+
+	case SettingTemperatureUnit:
+		unit := strings.ToUpper(strings.TrimPrefix(value, "°"))
+		if unit != "C" && unit != "F" {
+			return fmt.Errorf("temperature unit must be C or F")
+		}
+		s.TemperatureUnit = unit
+
+	case SettingLanguage:
+		for _, lang := range SupportedLanguages {
+			if value == lang {
+				s.Language = value
+				return nil
+			}
+		}
+		return fmt.Errorf("language must be one of %s", strings.Join(SupportedLanguages, ", "))
+
+	case SettingDefaultInterval:
+		interval, err := strconv.Atoi(value)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("interval must be a positive number of minutes")
+		}
+		s.DefaultInterval = interval
+
+	case SettingSilent:
+		switch strings.ToLower(value) {
+		case "on", "true", "yes", "1":
+			s.SilentNotifications = true
+		case "off", "false", "no", "0":
+			s.SilentNotifications = false
+		default:
+			return fmt.Errorf("silent must be on or off")
+		}
+
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	return nil
+}
+
+const settingsColumns = `user_id, chat_id, timezone, temperature_unit, language,
+	default_interval, silent_notifications, updated_at`
+
+func scanSettings(row *sql.Row) (*Settings, error) {
+	s := &Settings{}
+	err := row.Scan(
+		&s.UserID,
+		&s.ChatID,
+		&s.Timezone,
+		&s.TemperatureUnit,
+		&s.Language,
+		&s.DefaultInterval,
+		&s.SilentNotifications,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetSettings returns a user's settings for a chat, or the defaults if they
+// have not saved any.
+func (d *Database) GetSettings(userID, chatID int64) (*Settings, error) {
+	query := `SELECT ` + settingsColumns + ` FROM user_settings WHERE user_id = ? AND chat_id = ?`
+
+	s, err := scanSettings(d.db.QueryRow(query, userID, chatID))
+	if err == sql.ErrNoRows {
+		return DefaultSettings(userID, chatID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting settings: %w", err)
+	}
+	return s, nil
+}
+
+// GetChatSettings returns the settings that apply to messages the bot sends
+// to a chat on its own, such as reminders. In a private chat these are the
+// owner's settings; in a group the most recently saved ones win.
+func (d *Database) GetChatSettings(chatID int64) (*Settings, error) {
+	query := `SELECT ` + settingsColumns + ` FROM user_settings
+		WHERE chat_id = ?
+		ORDER BY user_id = chat_id DESC, updated_at DESC
+		LIMIT 1`
+
+	s, err := scanSettings(d.db.QueryRow(query, chatID))
+	if err == sql.ErrNoRows {
+		return DefaultSettings(chatID, chatID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting chat settings: %w", err)
+	}
+	return s, nil
+}
+
+// SaveSettings inserts or replaces a user's settings for a chat.
+func (d *Database) SaveSettings(s *Settings) error {
+	query := `
+		INSERT INTO user_settings (` + settingsColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id, chat_id) DO UPDATE SET
+			timezone = excluded.timezone,
+			temperature_unit = excluded.temperature_unit,
+			language = excluded.language,
+			default_interval = excluded.default_interval,
+			silent_notifications = excluded.silent_notifications,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := d.db.Exec(query,
+		s.UserID,
+		s.ChatID,
+		s.Timezone,
+		s.TemperatureUnit,
+		s.Language,
+		s.DefaultInterval,
+		s.SilentNotifications,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving settings: %w", err)
+	}
+	return nil
+}
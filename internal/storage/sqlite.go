@@ -6,9 +6,13 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -17,8 +21,59 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// Storage modes accepted in Options.Mode.
+const (
+	ModeDefault = "default"
+	// ModeSDCard trades durability of low-value writes for fewer writes to
+	// the card. The database runs in WAL mode with synchronous=NORMAL and a
+	// larger autocheckpoint, and writes made through execLowValue are held
+	// in memory and flushed in one transaction every FlushInterval and on
+	// Close. On power failure the following can be lost:
+	//   - buffered low-value writes made since the last flush (reminder
+	//     trigger times, reminder history, probe results and metric
+	//     samples), and
+	//   - the last few transactions committed since the last WAL sync.
+	// Reminders themselves and their status changes are never buffered, and
+	// WAL keeps the file consistent: a power cut rolls back, never corrupts.
+	ModeSDCard = "sdcard"
+)
+
+// walAutoCheckpointPages is the WAL size (in pages) at which SQLite copies
+// the log back into the main file in SD-card mode. The default is 1000.
+const walAutoCheckpointPages = 4000
+
+// timestampFormat matches what SQLite's CURRENT_TIMESTAMP produces, so values
+// written from Go sort and compare consistently with those written by SQL.
+const timestampFormat = "2006-01-02 15:04:05"
+
+type Options struct {
+	Mode          string
+	FlushInterval time.Duration
+
+	// IntegrityCheck is one of IntegrityQuick (the default), IntegrityFull
+	// or IntegrityOff. A failed check triggers automatic recovery, falling
+	// back to the newest backup in BackupDir.
+	IntegrityCheck string
+	BackupDir      string
+
+	// NoRecover makes a failed integrity check an error instead of moving
+	// the file aside, for tools that may run while the bot has it open.
+	NoRecover bool
+}
+
+// Database is the bot's SQLite store. In the default mode every write is
+// durable once the call returns. In SD-card mode low-value writes are held
+// in memory for up to Options.FlushInterval (5 minutes by default, sooner
+// once maxPendingWrites are queued) and are lost if the process dies
+// without Close; a flush that fails is retried on the next one.
 type Database struct {
-	db *sql.DB
+	db     *sql.DB
+	path   string
+	buffer *writeBuffer // nil unless running in SD-card mode
+
+	recovery *RecoveryReport // set if the file had to be recovered on open
+
+	closeOnce sync.Once
 }
 
 type Reminder struct {
@@ -39,30 +94,103 @@ type Migration struct {
 	SQL        string
 }
 
-func NewDatabase(dbPath string) (*Database, error) {
+func NewDatabase(dbPath string, opts Options) (*Database, error) {
+	// Check the file survived whatever happened since it was last closed
+	level := opts.IntegrityCheck
+	if level == "" {
+		level = IntegrityQuick
+	}
+	problems, err := checkIntegrity(dbPath, level)
+	if err != nil {
+		return nil, err
+	}
+
+	var recovery *RecoveryReport
+	if len(problems) > 0 && opts.NoRecover {
+		return nil, fmt.Errorf("database failed its integrity check with %d problems (first: %s); start the bot to recover it", len(problems), problems[0])
+	}
+	if len(problems) > 0 {
+		log.Printf("Database integrity check found %d problems, attempting recovery (first: %s)", len(problems), problems[0])
+		recovery, err = recoverDatabase(dbPath, opts.BackupDir, problems)
+		if err != nil {
+			return nil, fmt.Errorf("error recovering corrupt database: %w", err)
+		}
+		log.Printf("Database recovered by %s; damaged file kept at %s", recovery.Method, recovery.DamagedPath)
+	}
+
+	// Pragmas go in the DSN so every pooled connection gets them, not just
+	// the one that happens to run a PRAGMA statement.
+	// busy_timeout lets the admin CLI work on the file while the bot runs.
+	dsn := dbPath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if opts.Mode == ModeSDCard {
+		dsn += "&_pragma=journal_mode(WAL)" +
+			"&_pragma=synchronous(NORMAL)" +
+			"&_pragma=temp_store(MEMORY)" +
+			fmt.Sprintf("&_pragma=wal_autocheckpoint(%d)", walAutoCheckpointPages)
+	}
+
 	// Open database connection
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("error enabling foreign keys: %w", err)
+	// Make sure the file is actually reachable before migrating
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 
 	database := &Database{
-		db: db,
+		db:       db,
+		path:     dbPath,
+		recovery: recovery,
 	}
 
 	// Always run migrate to check for and apply new migrations
 	if err := database.migrate(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("error running migrations: %w", err)
 	}
 
+	if opts.Mode == ModeSDCard {
+		interval := opts.FlushInterval
+		if interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		database.buffer = newWriteBuffer(db, interval)
+	}
+
 	return database, nil
 }
 
+// Recovery returns the report of the recovery performed when the database
+// was opened, or nil if the file passed its integrity check.
+func (d *Database) Recovery() *RecoveryReport {
+	return d.recovery
+}
+
+// execLowValue runs a write whose loss on power failure is acceptable. In
+// SD-card mode it is queued and applied on the next flush; otherwise it runs
+// immediately.
+func (d *Database) execLowValue(query string, args ...interface{}) error {
+	if d.buffer != nil {
+		d.buffer.add(query, args...)
+		return nil
+	}
+	_, err := d.db.Exec(query, args...)
+	return err
+}
+
+// Flush writes out any buffered low-value writes. It is a no-op outside
+// SD-card mode.
+func (d *Database) Flush() error {
+	if d.buffer == nil {
+		return nil
+	}
+	return d.buffer.flush()
+}
+
 func (d *Database) initMigrationTable() error {
 	// Create migration tracking table
 	query := `
@@ -274,12 +402,14 @@ func (d *Database) UpdateReminderStatus(id int64, status string) error {
 	return nil
 }
 
-// UpdateReminderTrigger updates the last_triggered and next_trigger times
+// UpdateReminderTrigger updates the last_triggered and next_trigger times.
+// This is a low-value write: in SD-card mode it is buffered, so callers must
+// not read the new times back from the database.
 func (d *Database) UpdateReminderTrigger(id int64) error {
 	query := `
 		UPDATE reminders 
-		SET last_triggered = CURRENT_TIMESTAMP,
-			next_trigger = datetime('now', ? || ' minutes')
+		SET last_triggered = ?,
+			next_trigger = ?
 		WHERE id = ?
 	`
 	
@@ -288,18 +418,18 @@ func (d *Database) UpdateReminderTrigger(id int64) error {
 	if err != nil {
 		return fmt.Errorf("error getting reminder: %w", err)
 	}
-	
-	result, err := d.db.Exec(query, reminder.Interval, id)
-	if err != nil {
-		return fmt.Errorf("error updating reminder trigger: %w", err)
+	if reminder == nil {
+		return fmt.Errorf("reminder not found")
 	}
 
-	rows, err := result.RowsAffected()
+	// Times are computed here rather than with datetime('now') so a buffered
+	// write records when the reminder fired, not when it was flushed.
+	now := time.Now().UTC()
+	next := now.Add(time.Duration(reminder.Interval) * time.Minute)
+
+	err = d.execLowValue(query, now.Format(timestampFormat), next.Format(timestampFormat), id)
 	if err != nil {
-		return fmt.Errorf("error getting rows affected: %w", err)
-	}
-	if rows == 0 {
-		return fmt.Errorf("reminder not found")
+		return fmt.Errorf("error updating reminder trigger: %w", err)
 	}
 
 	return nil
@@ -364,14 +494,15 @@ func (d *Database) DeleteReminder(id int64) error {
 	return nil
 }
 
-// AddReminderHistory adds a history entry for a reminder
+// AddReminderHistory adds a history entry for a reminder. Like
+// UpdateReminderTrigger this is a low-value write.
 func (d *Database) AddReminderHistory(reminderID int64, status string) error {
 	query := `
-		INSERT INTO reminder_history (reminder_id, status)
-		VALUES (?, ?)
+		INSERT INTO reminder_history (reminder_id, triggered_at, status)
+		VALUES (?, ?, ?)
 	`
 	
-	_, err := d.db.Exec(query, reminderID, status)
+	err := d.execLowValue(query, reminderID, time.Now().UTC().Format(timestampFormat), status)
 	if err != nil {
 		return fmt.Errorf("error adding reminder history: %w", err)
 	}
@@ -425,7 +556,110 @@ func (d *Database) GetAllActiveReminders() ([]*Reminder, error) {
 	return reminders, nil
 }
 
-// Close closes the database connection
+// ListAllReminders returns every reminder regardless of status or chat
+func (d *Database) ListAllReminders() ([]*Reminder, error) {
+	query := `
+		SELECT id, chat_id, type, interval, status, message, 
+			   created_at, last_triggered, next_trigger
+		FROM reminders
+		ORDER BY id ASC
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []*Reminder
+	for rows.Next() {
+		reminder := &Reminder{}
+		err := rows.Scan(
+			&reminder.ID,
+			&reminder.ChatID,
+			&reminder.Type,
+			&reminder.Interval,
+			&reminder.Status,
+			&reminder.Message,
+			&reminder.CreatedAt,
+			&reminder.LastTriggered,
+			&reminder.NextTrigger,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, nil
+}
+
+// Backup writes a consistent copy of the database to path using VACUUM INTO.
+// Buffered writes are flushed first so the copy includes them. path must not
+// exist yet.
+func (d *Database) Backup(path string) error {
+	if err := d.Flush(); err != nil {
+		return fmt.Errorf("error flushing before backup: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating backup directory: %w", err)
+	}
+	if _, err := d.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("error backing up database: %w", err)
+	}
+	return nil
+}
+
+// BackupFileName returns a timestamped file name for a backup of dbPath,
+// e.g. "database-20250413-101500.db".
+func BackupFileName(dbPath string, t time.Time) string {
+	base := strings.TrimSuffix(filepath.Base(dbPath), filepath.Ext(dbPath))
+	return fmt.Sprintf("%s-%s.db", base, t.Format("20060102-150405"))
+}
+
+// LatestBackupTime returns when the newest backup of dbPath in dir was
+// written, or the zero time if there is none.
+func LatestBackupTime(dir, dbPath string) time.Time {
+	matches := backupFiles(dir, dbPath)
+	if len(matches) == 0 {
+		return time.Time{}
+	}
+	info, err := os.Stat(matches[len(matches)-1])
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// PruneBackups deletes all but the newest keep backups of dbPath in dir and
+// returns how many it deleted.
+func PruneBackups(dir, dbPath string, keep int) (int, error) {
+	matches := backupFiles(dir, dbPath)
+	deleted := 0
+	for i := 0; i < len(matches)-keep; i++ {
+		if err := os.Remove(matches[i]); err != nil {
+			return deleted, fmt.Errorf("error deleting old backup: %w", err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Close flushes buffered writes, checkpoints the WAL and closes the
+// database connection. It is safe to call more than once.
 func (d *Database) Close() error {
-	return d.db.Close()
+	var err error
+	d.closeOnce.Do(func() {
+		if d.buffer != nil {
+			if flushErr := d.buffer.close(); flushErr != nil {
+				log.Printf("Error flushing buffered writes on close: %v", flushErr)
+			}
+			// Fold the WAL back into the main file so nothing depends on it
+			if _, cpErr := d.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); cpErr != nil {
+				log.Printf("Error checkpointing WAL on close: %v", cpErr)
+			}
+		}
+		err = d.db.Close()
+	})
+	return err
 }
\ No newline at end of file
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WatchedProcess is a process the watchdog expects to be running. MatchType
+// is "name" (exact process name), "cmdline" (regular expression over the
+// command line) or "pidfile" (path of a file holding the PID).
+type WatchedProcess struct {
+	ID        int64
+	Name      string
+	MatchType string
+	Pattern   string
+	CreatedAt time.Time
+}
+
+// CreateWatch stores a new watched process and returns its ID.
+func (d *Database) CreateWatch(w *WatchedProcess) (int64, error) {
+	query := `
+		INSERT INTO watched_processes (name, match_type, pattern)
+		VALUES (?, ?, ?)
+	`
+
+	result, err := d.db.Exec(query, w.Name, w.MatchType, w.Pattern)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return 0, fmt.Errorf("a watch named %q already exists", w.Name)
+		}
+		return 0, fmt.Errorf("error creating watch: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error getting last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// ListWatches returns every watched process ordered by ID.
+func (d *Database) ListWatches() ([]*WatchedProcess, error) {
+	query := `
+		SELECT id, name, match_type, pattern, created_at
+		FROM watched_processes
+		ORDER BY id ASC
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying watches: %w", err)
+	}
+	defer rows.Close()
+
+	var watches []*WatchedProcess
+	for rows.Next() {
+		w := &WatchedProcess{}
+		if err := rows.Scan(&w.ID, &w.Name, &w.MatchType, &w.Pattern, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning watch: %w", err)
+		}
+		watches = append(watches, w)
+	}
+
+	return watches, rows.Err()
+}
+
+// DeleteWatch deletes a watched process
+func (d *Database) DeleteWatch(id int64) error {
+	result, err := d.db.Exec("DELETE FROM watched_processes WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting watch: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("watch not found")
+	}
+
+	return nil
+}
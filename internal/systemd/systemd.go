@@ -0,0 +1,180 @@
+// Package systemd inspects and controls an allowlist of systemd units
+// through systemctl.
+package systemd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mypibot-go/internal/command"
+)
+
+// Actions that change a unit's state.
+const (
+	ActionStart   = "start"
+	ActionStop    = "stop"
+	ActionRestart = "restart"
+)
+
+// commandTimeout bounds every systemctl call. Starting a slow unit can take
+// a while, so this is generous.
+const commandTimeout = 90 * time.Second
+
+// showProperties are the properties Status asks systemctl for.
+var showProperties = []string{
+	"Id", "Description", "LoadState", "ActiveState", "SubState", "Result",
+	"MainPID", "StateChangeTimestamp", "MemoryCurrent", "NRestarts",
+}
+
+// timestampLayout is how systemctl show prints timestamps, e.g.
+// "Sat 2026-10-17 09:12:44 IST", without the zone. Go gives zone
+// abbreviations it does not know a zero offset, so the time is read in the
+// local zone instead: systemctl prints local time and the bot runs on the
+// same machine.
+const timestampLayout = "Mon 2006-01-02 15:04:05"
+
+// UnitStatus is the parsed output of "systemctl show".
+type UnitStatus struct {
+	ID          string
+	Description string
+	LoadState   string // loaded, not-found, masked, ...
+	ActiveState string // active, inactive, failed, activating, ...
+	SubState    string // running, exited, dead, ...
+	Result      string // success, exit-code, signal, ...
+	MainPID     int
+	Since       time.Time // zero if unknown
+	Memory      uint64    // bytes; zero if accounting is off
+	HasMemory   bool
+	Restarts    int // automatic restarts by systemd
+}
+
+// ParseShow parses "systemctl show" output, one Key=Value per line.
+func ParseShow(out string) (*UnitStatus, error) {
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			props[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if props["Id"] == "" {
+		return nil, fmt.Errorf("unexpected systemctl show output")
+	}
+
+	s := &UnitStatus{
+		ID:          props["Id"],
+		Description: props["Description"],
+		LoadState:   props["LoadState"],
+		ActiveState: props["ActiveState"],
+		SubState:    props["SubState"],
+		Result:      props["Result"],
+	}
+	s.MainPID, _ = strconv.Atoi(props["MainPID"])
+	s.Restarts, _ = strconv.Atoi(props["NRestarts"])
+	// "[not set]" or the maximum uint64 when memory accounting is off
+	if mem, err := strconv.ParseUint(props["MemoryCurrent"], 10, 64); err == nil && mem != ^uint64(0) {
+		s.Memory, s.HasMemory = mem, true
+	}
+	if ts := strings.Fields(props["StateChangeTimestamp"]); len(ts) >= 3 {
+		if t, err := time.ParseInLocation(timestampLayout, strings.Join(ts[:3], " "), time.Local); err == nil {
+			s.Since = t
+		}
+	}
+	return s, nil
+}
+
+// Manager runs systemctl for allowlisted units.
+type Manager struct {
+	runner  command.Runner
+	allowed map[string]bool
+	units   []string
+}
+
+// NewManager allows the given units. Names without a type suffix are taken
+// to be services, so "nginx" allows "nginx.service".
+func NewManager(runner command.Runner, units []string) *Manager {
+	m := &Manager{runner: runner, allowed: make(map[string]bool)}
+	for _, unit := range units {
+		unit = NormalizeUnit(unit)
+		if !m.allowed[unit] {
+			m.allowed[unit] = true
+			m.units = append(m.units, unit)
+		}
+	}
+	sort.Strings(m.units)
+	return m
+}
+
+// NormalizeUnit adds ".service" to names without a unit type suffix.
+func NormalizeUnit(unit string) string {
+	for _, suffix := range []string{".service", ".socket", ".timer", ".target", ".mount", ".path"} {
+		if strings.HasSuffix(unit, suffix) {
+			return unit
+		}
+	}
+	return unit + ".service"
+}
+
+// Units returns the allowlisted units, sorted.
+func (m *Manager) Units() []string {
+	return m.units
+}
+
+// Allowed reports whether unit is allowlisted.
+func (m *Manager) Allowed(unit string) bool {
+	return m.allowed[NormalizeUnit(unit)]
+}
+
+func (m *Manager) check(unit string) (string, error) {
+	unit = NormalizeUnit(unit)
+	if !m.allowed[unit] {
+		return "", fmt.Errorf("%s is not in SERVICE_UNITS", unit)
+	}
+	return unit, nil
+}
+
+// Status reads a unit's state. It needs no privileges.
+func (m *Manager) Status(ctx context.Context, unit string) (*UnitStatus, error) {
+	unit, err := m.check(unit)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	out, err := m.runner.Run(ctx, "systemctl", "show", unit, "--property="+strings.Join(showProperties, ","))
+	if err != nil {
+		return nil, fmt.Errorf("error running systemctl show %s: %w", unit, err)
+	}
+	return ParseShow(string(out))
+}
+
+// Control starts, stops or restarts a unit through "sudo -n systemctl".
+func (m *Manager) Control(ctx context.Context, action, unit string) error {
+	unit, err := m.check(unit)
+	if err != nil {
+		return err
+	}
+	switch action {
+	case ActionStart, ActionStop, ActionRestart:
+	default:
+		return fmt.Errorf("unknown action %q: use start, stop or restart", action)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	if _, err := command.Sudo(ctx, m.runner, "systemctl", action, unit); err != nil {
+		return fmt.Errorf("error running systemctl %s %s (make sure NOPASSWD is configured in sudoers): %w", action, unit, err)
+	}
+	return nil
+}
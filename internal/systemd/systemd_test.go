@@ -0,0 +1,201 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const nginxShow = `Id=nginx.service
+Description=A high performance web server and a reverse proxy server
+LoadState=loaded
+ActiveState=active
+SubState=running
+Result=success
+MainPID=812
+StateChangeTimestamp=Sat 2026-10-17 09:12:44 IST
+MemoryCurrent=7340032
+NRestarts=2
+`
+
+func TestParseShow(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    UnitStatus
+		wantErr bool
+	}{
+		{
+			name: "running",
+			out:  nginxShow,
+			want: UnitStatus{
+				ID:          "nginx.service",
+				Description: "A high performance web server and a reverse proxy server",
+				LoadState:   "loaded",
+				ActiveState: "active",
+				SubState:    "running",
+				Result:      "success",
+				MainPID:     812,
+				Since:       time.Date(2026, 10, 17, 9, 12, 44, 0, time.Local),
+				Memory:      7340032,
+				HasMemory:   true,
+				Restarts:    2,
+			},
+		},
+		{
+			name: "failed without accounting",
+			out: "Id=app.service\nLoadState=loaded\nActiveState=failed\nSubState=failed\nResult=exit-code\n" +
+				"MainPID=0\nStateChangeTimestamp=Fri 2026-10-16 23:01:02 UTC\nMemoryCurrent=[not set]\nNRestarts=5\n",
+			want: UnitStatus{
+				ID:          "app.service",
+				LoadState:   "loaded",
+				ActiveState: "failed",
+				SubState:    "failed",
+				Result:      "exit-code",
+				Since:       time.Date(2026, 10, 16, 23, 1, 2, 0, time.Local),
+				Restarts:    5,
+			},
+		},
+		{
+			name: "memory at max",
+			out:  "Id=idle.service\nActiveState=inactive\nMemoryCurrent=18446744073709551615\nStateChangeTimestamp=\n",
+			want: UnitStatus{ID: "idle.service", ActiveState: "inactive"},
+		},
+		{
+			name: "value with equals sign",
+			out:  "Id=x.service\nDescription=a=b\n",
+			want: UnitStatus{ID: "x.service", Description: "a=b"},
+		},
+		{name: "empty", out: "", wantErr: true},
+		{name: "no id", out: "ActiveState=active\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseShow(tt.out)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseShow() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Since.Equal(tt.want.Since) {
+				t.Errorf("Since = %v, want %v", got.Since, tt.want.Since)
+			}
+			got.Since, tt.want.Since = time.Time{}, time.Time{}
+			if *got != tt.want {
+				t.Errorf("ParseShow() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeRunner records commands and returns canned output.
+type fakeRunner struct {
+	calls [][]string
+	out   string
+	err   error
+}
+
+func (r *fakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return []byte(r.out), r.err
+}
+
+func TestNewManager(t *testing.T) {
+	m := NewManager(&fakeRunner{}, []string{"nginx", "mosquitto.service", "backup.timer", "nginx.service"})
+
+	want := []string{"backup.timer", "mosquitto.service", "nginx.service"}
+	if got := m.Units(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Units() = %v, want %v", got, want)
+	}
+	for unit, allowed := range map[string]bool{
+		"nginx": true, "nginx.service": true, "backup.timer": true, "backup": false, "ssh": false,
+	} {
+		if m.Allowed(unit) != allowed {
+			t.Errorf("Allowed(%q) = %v, want %v", unit, !allowed, allowed)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	runner := &fakeRunner{out: nginxShow}
+	m := NewManager(runner, []string{"nginx"})
+
+	status, err := m.Status(context.Background(), "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ID != "nginx.service" || status.MainPID != 812 {
+		t.Errorf("Status() = %+v", status)
+	}
+	want := []string{"systemctl", "show", "nginx.service", "--property=" + strings.Join(showProperties, ",")}
+	if len(runner.calls) != 1 || !reflect.DeepEqual(runner.calls[0], want) {
+		t.Errorf("ran %q, want %q", runner.calls, want)
+	}
+
+	if _, err := m.Status(context.Background(), "ssh"); err == nil {
+		t.Error("Status() of a unit that is not allowed succeeded")
+	}
+	if len(runner.calls) != 1 {
+		t.Errorf("systemctl ran for a unit that is not allowed: %q", runner.calls)
+	}
+}
+
+func TestControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  string
+		unit    string
+		runErr  error
+		out     string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "restart", action: ActionRestart, unit: "nginx",
+			want: []string{"sudo", "-n", "systemctl", "restart", "nginx.service"},
+		},
+		{
+			name: "stop timer", action: ActionStop, unit: "backup.timer",
+			want: []string{"sudo", "-n", "systemctl", "stop", "backup.timer"},
+		},
+		{name: "not allowed", action: ActionStart, unit: "ssh", wantErr: "not in SERVICE_UNITS"},
+		{name: "unknown action", action: "reload", unit: "nginx", wantErr: "unknown action"},
+		{
+			name: "sudo refused", action: ActionStart, unit: "nginx",
+			runErr: errors.New("exit status 1"), out: "sudo: a password is required\n",
+			want:    []string{"sudo", "-n", "systemctl", "start", "nginx.service"},
+			wantErr: "a password is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{out: tt.out, err: tt.runErr}
+			m := NewManager(runner, []string{"nginx", "backup.timer"})
+
+			err := m.Control(context.Background(), tt.action, tt.unit)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Control(): %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Control() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+
+			var ran []string
+			if len(runner.calls) > 0 {
+				ran = runner.calls[0]
+			}
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("ran %q, want %q", ran, tt.want)
+			}
+		})
+	}
+}